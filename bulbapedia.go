@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+
+	"github.com/gocolly/colly"
+
+	"main/scrape"
+)
+
+// Bulbapedia lists of every Pokémon
+const (
+	BulbapediaExpURL    = "https://bulbapedia.bulbagarden.net/wiki/List_of_Pok%C3%A9mon_by_effort_value_yield_(Generation_IX)"
+	BulbapediaGrowthURL = "https://bulbapedia.bulbagarden.net/wiki/List_of_Pok%C3%A9mon_by_experience_type"
+)
+
+// BulbapediaSource reads base EXP and EV yields from Bulbapedia's effort value yield list,
+// and growth rates from its experience type list
+type BulbapediaSource struct {
+	SaveDir string // Directory to keep a snapshot of the page in, if set
+}
+
+func (s *BulbapediaSource) Name() string { return "Bulbapedia" }
+
+func (s *BulbapediaSource) Lists(ctx context.Context) (map[int]scrape.ListEntry, error) {
+	yields, err := s.fetch(BulbapediaExpURL, "exp")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := scrape.ParseYieldTable(yields)
+	if err != nil {
+		return nil, err
+	}
+	growth, err := s.fetch(BulbapediaGrowthURL, "growth")
+	if err != nil {
+		return nil, err
+	}
+	return entries, scrape.ParseGrowthTable(growth, entries)
+}
+
+// fetch downloads a page, keeping a snapshot of it if SaveDir is set
+func (s *BulbapediaSource) fetch(url string, page string) (string, error) {
+	c := colly.NewCollector(
+		colly.AllowedDomains("bulbapedia.bulbagarden.net"),
+	)
+	var html string
+	c.OnResponse(func(r *colly.Response) {
+		html = string(r.Body)
+	})
+	if err := c.Visit(url); err != nil {
+		return "", err
+	}
+	if err := saveSnapshot(s.SaveDir, BulbapediaSite, page, html); err != nil {
+		return "", err
+	}
+	return html, nil
+}
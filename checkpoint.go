@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"sort"
+
+	"main/pokedex"
+)
+
+// Checkpoint appends every fetched Pokémon to a newline-delimited JSON file, so an interrupted
+// crawl can resume without fetching them again
+type Checkpoint struct {
+	file *os.File
+}
+
+// checkpointHeader is the first line of a checkpoint, naming the crawl its records come from
+type checkpointHeader struct {
+	Source string `json:"source"`
+	First  int    `json:"first"`
+	Last   int    `json:"last"`
+}
+
+// crawlFailure is a number that failed every retry, as written to the failure report
+type crawlFailure struct {
+	Number int    `json:"number"`
+	Error  string `json:"error"`
+}
+
+// loadCheckpoint reads the Pokémon fetched by earlier runs of the same crawl, starting empty if there is
+// no checkpoint yet. A checkpoint of another source or range is removed, so records read from snapshots
+// never stand in for pages of the site. A line cut short by a crash is skipped so that number is fetched again.
+func loadCheckpoint(filename string, header checkpointHeader) (map[int]pokedex.Pokemon, error) {
+	fetched := make(map[int]pokedex.Pokemon)
+	file, err := os.Open(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fetched, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	var found checkpointHeader
+	if scanner.Scan() {
+		json.Unmarshal(scanner.Bytes(), &found)
+	}
+	if found != header {
+		file.Close()
+		log.Printf("Discarding checkpoint %s, it is not from a %s crawl of #%d-#%d", filename, header.Source, header.First, header.Last)
+		if err := os.Remove(filename); err != nil {
+			return nil, fmt.Errorf("failed to remove old checkpoint: %v", err)
+		}
+		return fetched, nil
+	}
+	for line := 2; scanner.Scan(); line++ {
+		var pokemon pokedex.Pokemon
+		if err := json.Unmarshal(scanner.Bytes(), &pokemon); err != nil {
+			log.Printf("Skipping line %d of checkpoint %s: %v", line, filename, err)
+			continue
+		}
+		fetched[pokemon.Number] = pokemon
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %v", err)
+	}
+	return fetched, nil
+}
+
+// openCheckpoint opens a checkpoint file for appending, starting a new one with its header
+func openCheckpoint(filename string, header checkpointHeader) (*Checkpoint, error) {
+	file, err := os.OpenFile(filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open checkpoint: %v", err)
+	}
+	// Start on a fresh line if a crash cut the last entry short
+	if data, err := os.ReadFile(filename); err == nil && len(data) > 0 && data[len(data)-1] != '\n' {
+		if _, err := file.Write([]byte("\n")); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to write checkpoint: %v", err)
+		}
+	}
+	checkpoint := &Checkpoint{file: file}
+	if info, err := file.Stat(); err == nil && info.Size() == 0 {
+		if err := checkpoint.write(header); err != nil {
+			file.Close()
+			return nil, err
+		}
+	}
+	return checkpoint, nil
+}
+
+// Add appends one Pokémon to the checkpoint and flushes it to disk
+func (c *Checkpoint) Add(pokemon pokedex.Pokemon) error {
+	return c.write(pokemon)
+}
+
+// write appends one line to the checkpoint and flushes it to disk
+func (c *Checkpoint) write(record any) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint entry: %v", err)
+	}
+	if _, err := c.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %v", err)
+	}
+	return c.file.Sync()
+}
+
+// Close closes the checkpoint file
+func (c *Checkpoint) Close() error {
+	return c.file.Close()
+}
+
+// writeFailureReport lists the numbers that failed every retry, or removes a stale report when none did
+func writeFailureReport(filename string, failures []crawlFailure) error {
+	if len(failures) == 0 {
+		if err := os.Remove(filename); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("failed to remove old failure report: %v", err)
+		}
+		return nil
+	}
+	sort.Slice(failures, func(i, j int) bool { return failures[i].Number < failures[j].Number })
+	data, err := json.MarshalIndent(failures, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode failure report: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write failure report: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"main/pokedex"
+)
+
+// writeDiffReport writes the diff as JSON
+func writeDiffReport(filename string, diff pokedex.DexDiff) error {
+	data, err := json.MarshalIndent(diff, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode diff report: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write diff report: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"main/pokedex"
+)
+
+// export writes the species dex, or one player's captured collection, in the format picked by flag,
+// and returns the exit status
+func export(args []string) int {
+	flags := flag.NewFlagSet("export", flag.ExitOnError)
+	format := flags.String("format", "csv", "output format: "+strings.Join(pokedex.ExportFormats, ", "))
+	dataDir := pokedex.DataDirFlagSet(flags)
+	dexFile := flags.String("dex", "", "pokedex file to export (default <data>/pokedex.json)")
+	player := flags.String("player", "", "export this PokeCat player's captured Pokémon instead of the dex")
+	store := flags.String("store", "", "PokeCat save file read by -player (default <data>/player_pokemon.json)")
+	out := flags.String("out", "", "file to write to instead of standard output")
+	flags.Parse(args)
+	if *dexFile == "" {
+		*dexFile = filepath.Join(*dataDir, pokedex.DexFile)
+	}
+	if *store == "" {
+		*store = filepath.Join(*dataDir, pokedex.PlayerStoreFile)
+	}
+
+	var pokemons []pokedex.Pokemon
+	var err error
+	if *player != "" {
+		var save pokedex.PlayerSave
+		save, err = pokedex.LoadPlayer(*store, *player)
+		pokemons = save.Pokemons
+	} else {
+		pokemons, err = pokedex.Read(*dexFile)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to create %s: %v\n", *out, err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+	if err := pokedex.Export(w, *format, pokemons); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"io"
+	"math/rand"
+	"net"
+)
+
+// Strategy makes battle decisions for a player in automatic mode or for the AI trainer
+type Strategy interface {
+	Name() string
+	ChooseAction(b *Battle, player *Player) *Action
+	ChooseReplacement(b *Battle, player *Player) *BattlePokemon
+}
+
+// strategies lists the AI strategies players can pick, in menu order
+var strategies = []Strategy{RandomStrategy{}, GreedyStrategy{}, LookaheadStrategy{}}
+
+// RandomStrategy uses a random move and sends out a random Pokémon
+type RandomStrategy struct{}
+
+func (RandomStrategy) Name() string { return "random" }
+
+func (RandomStrategy) ChooseAction(b *Battle, player *Player) *Action {
+	action := newAction(b, player)
+	action.Slot = randomMove(player.Active)
+	return action
+}
+
+func (RandomStrategy) ChooseReplacement(b *Battle, player *Player) *BattlePokemon {
+	bench := healthyBench(player)
+	if len(bench) == 0 {
+		return nil
+	}
+	return bench[rand.Intn(len(bench))]
+}
+
+// GreedyStrategy uses the move with the highest expected damage against the opposing Pokémon,
+// which favours type advantages, and sends out the Pokémon that hits the opponent hardest
+type GreedyStrategy struct{}
+
+func (GreedyStrategy) Name() string { return "greedy" }
+
+func (GreedyStrategy) ChooseAction(b *Battle, player *Player) *Action {
+	action := newAction(b, player)
+	action.Slot, _ = bestMove(player.Active, b.opponent(player).Active)
+	return action
+}
+
+func (GreedyStrategy) ChooseReplacement(b *Battle, player *Player) *BattlePokemon {
+	target := b.opponent(player).Active
+	var best *BattlePokemon
+	bestDamage := -1.0
+	for _, pokemon := range healthyBench(player) {
+		if _, damage := bestMove(pokemon, target); damage > bestDamage {
+			best, bestDamage = pokemon, damage
+		}
+	}
+	return best
+}
+
+// LookaheadStrategy considers every move and switch, assumes the opponent answers with its
+// greedy best move, and picks the option that leaves it furthest ahead in HP after the turn
+type LookaheadStrategy struct{}
+
+func (LookaheadStrategy) Name() string { return "lookahead" }
+
+func (LookaheadStrategy) ChooseAction(b *Battle, player *Player) *Action {
+	target := b.opponent(player).Active
+	best := newAction(b, player)
+	best.Slot, _ = bestMove(player.Active, target)
+	bestScore := scoreMove(player.Active, target, best.Move())
+
+	for _, slot := range player.Active.Moves {
+		if slot.PP <= 0 {
+			continue
+		}
+		if score := scoreMove(player.Active, target, slot.Move); score > bestScore {
+			best = newAction(b, player)
+			best.Slot, bestScore = slot, score
+		}
+	}
+	for _, pokemon := range healthyBench(player) {
+		// A switch spends the turn: the incoming Pokémon takes the opponent's best hit
+		if score := -damageTaken(target, pokemon); score > bestScore {
+			best = newAction(b, player)
+			best.Switch, bestScore = pokemon, score
+		}
+	}
+	return best
+}
+
+func (LookaheadStrategy) ChooseReplacement(b *Battle, player *Player) *BattlePokemon {
+	target := b.opponent(player).Active
+	var best *BattlePokemon
+	var bestScore float64
+	for _, pokemon := range healthyBench(player) {
+		move := &struggle
+		if slot, _ := bestMove(pokemon, target); slot != nil {
+			move = slot.Move
+		}
+		if score := scoreMove(pokemon, target, move); best == nil || score > bestScore {
+			best, bestScore = pokemon, score
+		}
+	}
+	return best
+}
+
+// scoreMove estimates the HP swing of a turn where attacker uses move and the defender replies
+// with its best move, as fractions of max HP. Knocking the defender out before it moves scores a bonus.
+func scoreMove(attacker *BattlePokemon, defender *BattlePokemon, move *Move) float64 {
+	dealt := expectedDamage(attacker, defender, move)
+	if dealt >= float64(defender.HP) {
+		reply := &struggle
+		if slot, _ := bestMove(defender, attacker); slot != nil {
+			reply = slot.Move
+		}
+		if movesFirst(attacker, move, defender, reply) {
+			return 1 + float64(defender.HP)/float64(defender.MaxHP)
+		}
+		dealt = float64(defender.HP)
+	}
+	return dealt/float64(defender.MaxHP) - damageTaken(defender, attacker)
+}
+
+// damageTaken is the fraction of the target's max HP the attacker's best move is expected to take
+func damageTaken(attacker *BattlePokemon, target *BattlePokemon) float64 {
+	_, damage := bestMove(attacker, target)
+	if damage > float64(target.HP) {
+		damage = float64(target.HP)
+	}
+	return damage / float64(target.MaxHP)
+}
+
+// movesFirst reports whether a move goes before the reply, ignoring Speed ties
+func movesFirst(attacker *BattlePokemon, move *Move, defender *BattlePokemon, reply *Move) bool {
+	if move.Priority != reply.Priority {
+		return move.Priority > reply.Priority
+	}
+	return attacker.Speed() > defender.Speed()
+}
+
+// bestMove returns the move slot with PP left and the highest expected damage, or nil for Struggle
+func bestMove(attacker *BattlePokemon, defender *BattlePokemon) (*MoveSlot, float64) {
+	var best *MoveSlot
+	bestDamage := expectedDamage(attacker, defender, &struggle)
+	for _, slot := range attacker.Moves {
+		if slot.PP <= 0 {
+			continue
+		}
+		if damage := expectedDamage(attacker, defender, slot.Move); best == nil || damage > bestDamage {
+			best, bestDamage = slot, damage
+		}
+	}
+	return best, bestDamage
+}
+
+// healthyBench returns the player's Pokémon that could be switched in
+func healthyBench(player *Player) []*BattlePokemon {
+	var bench []*BattlePokemon
+	for _, pokemon := range player.Pokemons {
+		if pokemon != player.Active && !pokemon.Fainted() {
+			bench = append(bench, pokemon)
+		}
+	}
+	return bench
+}
+
+// newAction starts an action for the player's active Pokémon against their opponent
+func newAction(b *Battle, player *Player) *Action {
+	return &Action{Player: player, Target: b.opponent(player), Pokemon: player.Active}
+}
+
+// newAITrainer creates a server-side trainer with a random team of size Pokémon, or the whole dex when it
+// is smaller, driven by the given strategy
+func newAITrainer(strategy Strategy, size int) *Player {
+	conn, discard := net.Pipe()
+	go io.Copy(io.Discard, discard)
+
+	trainer := &Player{
+		Name:     "AI Trainer (" + strategy.Name() + ")",
+		Strategy: strategy,
+		AI:       true,
+		Conn:     conn,
+	}
+	for _, i := range rand.Perm(dex.Len())[:min(size, dex.Len())] {
+		trainer.Team = append(trainer.Team, dex.At(i))
+	}
+	return trainer
+}
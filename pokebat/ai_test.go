@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+
+	"main/pokedex"
+)
+
+// testDex returns a dex of the given species, each with the same middling stats
+func testDex(t *testing.T, names ...string) *pokedex.Dex {
+	t.Helper()
+	var pokemons []pokedex.Pokemon
+	for i, name := range names {
+		pokemons = append(pokemons, pokedex.Pokemon{
+			Name:   name,
+			Types:  []string{"normal"},
+			Number: i + 1,
+			Stats:  pokedex.Stats{HP: 50, Attack: 50, Defense: 50, Speed: 50, SpAtk: 50, SpDef: 50},
+			Exp:    100,
+		})
+	}
+	built, err := pokedex.New(pokemons)
+	if err != nil {
+		t.Fatalf("failed to build test dex: %v", err)
+	}
+	return built
+}
+
+func TestNewAITrainerTeamSize(t *testing.T) {
+	defer func(saved *pokedex.Dex) { dex = saved }(dex)
+	tests := []struct {
+		species []string
+		size    int
+		want    int
+	}{
+		{[]string{"Pidgey", "Rattata", "Spearow", "Meowth"}, 3, 3},
+		{[]string{"Pidgey", "Rattata", "Spearow", "Meowth"}, 2, 2},
+		{[]string{"Pidgey", "Rattata", "Spearow", "Meowth"}, 1, 1},
+		{[]string{"Pidgey", "Rattata"}, 3, 2},
+		{[]string{"Pidgey"}, 3, 1},
+	}
+	for _, test := range tests {
+		dex = testDex(t, test.species...)
+		trainer := newAITrainer(RandomStrategy{}, test.size)
+		if len(trainer.Team) != test.want {
+			t.Errorf("AI team for a team of %d from a dex of %d has %d Pokémon, want %d", test.size, dex.Len(), len(trainer.Team), test.want)
+		}
+		seen := make(map[string]bool)
+		for _, pokemon := range trainer.Team {
+			if seen[pokemon.Name] {
+				t.Errorf("AI team has %s twice", pokemon.Name)
+			}
+			seen[pokemon.Name] = true
+		}
+		trainer.Conn.Close()
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import "main/pokedex"
+
+// StatStages holds the in-battle stat modifiers of a Pokémon, each between -6 and +6
+type StatStages struct {
+	Attack  int
+	Defense int
+	SpAtk   int
+	SpDef   int
+	Speed   int
+}
+
+// BattlePokemon is a Pokémon taking part in a battle. It is built from a pokedex entry
+// so that damage, PP and stat changes never touch the shared species data.
+type BattlePokemon struct {
+	Species *pokedex.Pokemon
+	Name    string
+	Types   []string
+	Level   int
+	MaxHP   int
+	HP      int
+	Stats   pokedex.Stats // Stats at Level, HP is the same as MaxHP
+	Status  string        // Non-volatile status condition such as "burn" or "paralysis", empty when healthy
+	Stages  StatStages
+	Moves   []*MoveSlot
+}
+
+// newBattlePokemon creates a fresh battle instance of a species at full HP and PP
+func newBattlePokemon(species *pokedex.Pokemon, level int, moves []Move) *BattlePokemon {
+	stats := species.Stats.AtLevel(level)
+	pokemon := &BattlePokemon{
+		Species: species,
+		Name:    species.Name,
+		Types:   species.Types,
+		Level:   level,
+		MaxHP:   stats.HP,
+		HP:      stats.HP,
+		Stats:   stats,
+	}
+	for _, move := range learnset(species, moves) {
+		pokemon.Moves = append(pokemon.Moves, &MoveSlot{Move: move, PP: move.PP})
+	}
+	return pokemon
+}
+
+// stageMultiplier returns the multiplier of a stat stage, from 2/8 at -6 up to 8/2 at +6
+func stageMultiplier(stage int) float64 {
+	if stage > 6 {
+		stage = 6
+	}
+	if stage < -6 {
+		stage = -6
+	}
+	if stage >= 0 {
+		return float64(2+stage) / 2
+	}
+	return 2 / float64(2-stage)
+}
+
+// Fainted reports whether the Pokémon can no longer battle
+func (p *BattlePokemon) Fainted() bool {
+	return p.HP <= 0
+}
+
+// TakeDamage lowers the Pokémon's HP without going below zero
+func (p *BattlePokemon) TakeDamage(damage int) {
+	p.HP -= damage
+	if p.HP < 0 {
+		p.HP = 0
+	}
+}
+
+// Attack returns the Pokémon's Attack including its stat stage
+func (p *BattlePokemon) Attack() int {
+	return int(float64(p.Stats.Attack) * stageMultiplier(p.Stages.Attack))
+}
+
+// Defense returns the Pokémon's Defense including its stat stage
+func (p *BattlePokemon) Defense() int {
+	return int(float64(p.Stats.Defense) * stageMultiplier(p.Stages.Defense))
+}
+
+// SpAtk returns the Pokémon's Sp. Atk including its stat stage
+func (p *BattlePokemon) SpAtk() int {
+	return int(float64(p.Stats.SpAtk) * stageMultiplier(p.Stages.SpAtk))
+}
+
+// SpDef returns the Pokémon's Sp. Def including its stat stage
+func (p *BattlePokemon) SpDef() int {
+	return int(float64(p.Stats.SpDef) * stageMultiplier(p.Stages.SpDef))
+}
+
+// Speed returns the Pokémon's Speed including its stat stage, halved while paralyzed
+func (p *BattlePokemon) Speed() int {
+	speed := int(float64(p.Stats.Speed) * stageMultiplier(p.Stages.Speed))
+	if p.Status == "paralysis" {
+		speed /= 2
+	}
+	return speed
+}
+
+// resetTeam builds fresh battle instances of the player's chosen team
+func resetTeam(player *Player, moves []Move) {
+	player.Pokemons = nil
+	for _, species := range player.Team {
+		player.Pokemons = append(player.Pokemons, newBattlePokemon(species, BattleLevel, moves))
+	}
+	player.Active = player.Pokemons[0]
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Damage formula constants
+const (
+	BattleLevel        = 50       // Level every Pokémon battles at
+	CriticalChance     = 1.0 / 24 // Chance of a critical hit
+	CriticalMultiplier = 1.5      // Damage multiplier of a critical hit
+	STABMultiplier     = 1.5      // Same-type attack bonus
+	MinRandomPercent   = 85       // Lowest roll of the random damage spread, in percent
+)
+
+// DamageResult is the outcome of the damage formula together with every factor that went into it
+type DamageResult struct {
+	Damage   int
+	Level    int
+	Power    int
+	Attack   int
+	Defense  int
+	Base     int     // Damage before modifiers
+	STAB     float64 // Same-type attack bonus
+	Critical bool
+	Random   float64 // Random spread between 0.85 and 1.00
+	Type     float64 // Type effectiveness
+	Category string
+	MoveName string
+	MoveType string
+	Attacker string
+	Defender string
+	Modifier float64 // Product of all modifiers
+}
+
+// calculateDamage applies the mainline damage formula:
+// ((2 * Level / 5 + 2) * Power * A / D) / 50 + 2, multiplied by critical, random, STAB and type modifiers
+func calculateDamage(attacker *BattlePokemon, defender *BattlePokemon, move *Move) DamageResult {
+	result := damageFactors(attacker, defender, move)
+	result.Random = float64(MinRandomPercent+rand.Intn(100-MinRandomPercent+1)) / 100
+	result.Critical = rand.Float64() < CriticalChance
+
+	result.Modifier = result.Random * result.STAB * result.Type
+	if result.Critical {
+		result.Modifier *= CriticalMultiplier
+	}
+
+	if result.Type == 0 {
+		return result
+	}
+	result.Damage = int(float64(result.Base) * result.Modifier)
+	if result.Damage < 1 {
+		result.Damage = 1
+	}
+	return result
+}
+
+// expectedDamage returns the average damage of a move including its accuracy, ignoring critical hits
+func expectedDamage(attacker *BattlePokemon, defender *BattlePokemon, move *Move) float64 {
+	result := damageFactors(attacker, defender, move)
+	averageRandom := float64(MinRandomPercent+100) / 200
+	return float64(result.Base) * averageRandom * result.STAB * result.Type * float64(move.Accuracy) / 100
+}
+
+// damageFactors fills in the parts of the damage formula that do not depend on chance
+func damageFactors(attacker *BattlePokemon, defender *BattlePokemon, move *Move) DamageResult {
+	result := DamageResult{
+		Level:    attacker.Level,
+		Power:    move.Power,
+		Attack:   attacker.Attack(),
+		Defense:  defender.Defense(),
+		STAB:     1.0,
+		Type:     typeMultiplier(move.Type, defender.Types),
+		Category: move.Category,
+		MoveName: move.Name,
+		MoveType: move.Type,
+		Attacker: attacker.Name,
+		Defender: defender.Name,
+	}
+	if move.IsSpecial() {
+		result.Attack = attacker.SpAtk()
+		result.Defense = defender.SpDef()
+	}
+	if result.Defense < 1 {
+		result.Defense = 1
+	}
+	for _, t := range attacker.Types {
+		if t == move.Type {
+			result.STAB = STABMultiplier
+		}
+	}
+	result.Base = (2*result.Level/5+2)*result.Power*result.Attack/result.Defense/50 + 2
+	return result
+}
+
+// Breakdown describes how the damage was calculated, for the verbose battle log
+func (d DamageResult) Breakdown() string {
+	return fmt.Sprintf("%s used %s (%s, %s) on %s: level %d, power %d, %d/%d A/D -> base %d; critical %t, random %.2f, STAB %.1f, type %.2f -> modifier %.3f -> %d damage",
+		d.Attacker, d.MoveName, d.MoveType, d.Category, d.Defender, d.Level, d.Power, d.Attack, d.Defense, d.Base,
+		d.Critical, d.Random, d.STAB, d.Type, d.Modifier, d.Damage)
+}
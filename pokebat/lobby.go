@@ -0,0 +1,314 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"main/pokedex"
+)
+
+// errLeftRoom is returned when a player cancels waiting for an opponent
+var errLeftRoom = errors.New("left the room")
+
+// Room is a battle between a host and a guest, open until the guest joins
+type Room struct {
+	ID       int
+	Name     string
+	Host     *Player
+	Guest    *Player
+	started  chan struct{} // Closed when a guest joins and the battle starts
+	finished chan struct{} // Closed when the battle ends
+}
+
+// Lobby tracks the logged in players and the rooms waiting for an opponent
+type Lobby struct {
+	mutex   sync.Mutex
+	players map[string]*Player
+	rooms   map[int]*Room // Open rooms by ID
+	nextID  int
+	battles int // Number of battles in progress
+}
+
+var lobby = &Lobby{
+	players: make(map[string]*Player),
+	rooms:   make(map[int]*Room),
+}
+
+// Enter adds a player to the lobby, failing if the name is already in use
+func (l *Lobby) Enter(name string, player *Player) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, exists := l.players[name]; exists {
+		return false
+	}
+	l.players[name] = player
+	return true
+}
+
+// Leave removes a player from the lobby and closes any room they were waiting in
+func (l *Lobby) Leave(player *Player) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	delete(l.players, player.Name)
+	for id, room := range l.rooms {
+		if room.Host == player {
+			delete(l.rooms, id)
+		}
+	}
+}
+
+// OpenRooms returns the rooms waiting for an opponent, oldest first, and the number of battles in progress
+func (l *Lobby) OpenRooms() ([]*Room, int) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	rooms := make([]*Room, 0, len(l.rooms))
+	for _, room := range l.rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms, l.battles
+}
+
+// Create opens a new room hosted by the player
+func (l *Lobby) Create(host *Player, name string) *Room {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	room := l.newRoom(host, name)
+	l.rooms[room.ID] = room
+	return room
+}
+
+// newRoom returns a room with the next ID without opening it, the caller must hold the mutex
+func (l *Lobby) newRoom(host *Player, name string) *Room {
+	l.nextID++
+	return &Room{
+		ID:       l.nextID,
+		Name:     name,
+		Host:     host,
+		started:  make(chan struct{}),
+		finished: make(chan struct{}),
+	}
+}
+
+// Join lets the player join an open room and starts its battle in a new goroutine
+func (l *Lobby) Join(id int, guest *Player) (*Room, error) {
+	l.mutex.Lock()
+	room, ok := l.rooms[id]
+	if !ok {
+		l.mutex.Unlock()
+		return nil, fmt.Errorf("room %d is not open", id)
+	}
+	if room.Host == guest {
+		l.mutex.Unlock()
+		return nil, fmt.Errorf("you cannot join your own room")
+	}
+	l.start(room, guest)
+	l.mutex.Unlock()
+	return room, nil
+}
+
+// QuickMatch joins the oldest room waiting for an opponent, or queues the player in a new one.
+// Both happen under one hold of the mutex, so two players quick matching at once are paired.
+func (l *Lobby) QuickMatch(player *Player) (room *Room, joined bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	var oldest *Room
+	for _, room := range l.rooms {
+		if room.Host != player && (oldest == nil || room.ID < oldest.ID) {
+			oldest = room
+		}
+	}
+	if oldest != nil {
+		l.start(oldest, player)
+		return oldest, true
+	}
+	room = l.newRoom(player, player.Name+"'s quick match")
+	l.rooms[room.ID] = room
+	return room, false
+}
+
+// Cancel closes a room that is still waiting for an opponent, reporting whether it was closed
+func (l *Lobby) Cancel(room *Room) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if _, ok := l.rooms[room.ID]; !ok {
+		return false
+	}
+	delete(l.rooms, room.ID)
+	return true
+}
+
+// StartAIBattle starts a single-player battle against a server-side trainer bringing as many Pokémon as the player
+func (l *Lobby) StartAIBattle(player *Player, strategy Strategy) *Room {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	room := l.newRoom(player, player.Name+" vs AI")
+	l.start(room, newAITrainer(strategy, len(player.Team)))
+	return room
+}
+
+// start closes the room to new players and runs its battle, the caller must hold the mutex
+func (l *Lobby) start(room *Room, guest *Player) {
+	delete(l.rooms, room.ID)
+	room.Guest = guest
+	l.battles++
+	close(room.started)
+	go func() {
+		runBattle(room)
+		l.mutex.Lock()
+		l.battles--
+		l.mutex.Unlock()
+	}()
+}
+
+// lobbyMenu shows the lobby to a player until they quit or disconnect
+func lobbyMenu(player *Player, owned []pokedex.Pokemon) error {
+	for {
+		player.Conn.Write([]byte("Lobby:\n1. List open rooms\n2. Create a room\n3. Join a room\n4. Quick match\n5. Battle the AI trainer\n6. Change team\n7. Change game mode\n8. Quit\nEnter your choice: "))
+		choice, err := player.ReadLine()
+		if err != nil {
+			return err
+		}
+
+		switch choice {
+		case "1":
+			listRooms(player)
+		case "2":
+			player.Conn.Write([]byte("Enter a room name: "))
+			name, err := player.ReadLine()
+			if err != nil {
+				return err
+			}
+			if name == "" {
+				name = player.Name + "'s room"
+			}
+			room := lobby.Create(player, name)
+			err = waitForOpponent(player, room)
+			if err != nil && err != errLeftRoom {
+				return err
+			}
+		case "3":
+			listRooms(player)
+			player.Conn.Write([]byte("Enter the room number to join: "))
+			line, err := player.ReadLine()
+			if err != nil {
+				return err
+			}
+			id, err := strconv.Atoi(line)
+			if err != nil {
+				player.Conn.Write([]byte("Invalid room number.\n"))
+				continue
+			}
+			room, err := lobby.Join(id, player)
+			if err != nil {
+				player.Conn.Write([]byte(fmt.Sprintf("Could not join: %v\n", err)))
+				continue
+			}
+			<-room.finished
+		case "4":
+			room, joined := lobby.QuickMatch(player)
+			if joined {
+				<-room.finished
+				continue
+			}
+			err := waitForOpponent(player, room)
+			if err != nil && err != errLeftRoom {
+				return err
+			}
+		case "5":
+			strategy, err := chooseTrainer(player)
+			if err != nil {
+				return err
+			}
+			room := lobby.StartAIBattle(player, strategy)
+			<-room.finished
+			room.Guest.Conn.Close()
+		case "6":
+			if err := chooseTeam(player, owned); err != nil {
+				return err
+			}
+		case "7":
+			if err := chooseMode(player); err != nil {
+				return err
+			}
+		case "8":
+			return nil
+		default:
+			player.Conn.Write([]byte("Invalid choice. Try again.\n"))
+		}
+	}
+}
+
+// listRooms shows the open rooms to a player
+func listRooms(player *Player) {
+	rooms, battles := lobby.OpenRooms()
+	if len(rooms) == 0 {
+		player.Conn.Write([]byte(fmt.Sprintf("No open rooms, %d battles in progress. Create one or use quick match!\n", battles)))
+		return
+	}
+	var list strings.Builder
+	list.WriteString(fmt.Sprintf("Open rooms (%d battles in progress):\n", battles))
+	for _, room := range rooms {
+		list.WriteString(fmt.Sprintf("%d. %s (host: %s)\n", room.ID, room.Name, room.Host.Name))
+	}
+	player.Conn.Write([]byte(list.String()))
+}
+
+// waitForOpponent keeps the host in their room until someone joins and the battle ends,
+// or until they type 'cancel'
+func waitForOpponent(player *Player, room *Room) error {
+	player.Conn.Write([]byte(fmt.Sprintf("Waiting for an opponent in room %d (%s). Type 'cancel' to leave.\n", room.ID, room.Name)))
+	for {
+		// Once the battle starts it reads the player's input, so stop reading here before taking another line
+		select {
+		case <-room.started:
+			<-room.finished
+			return nil
+		default:
+		}
+		select {
+		case <-room.started:
+			continue
+		case line, ok := <-player.input:
+			if !ok {
+				lobby.Cancel(room)
+				return errors.New("disconnected while waiting")
+			}
+			if line != "cancel" {
+				player.Conn.Write([]byte("Still waiting for an opponent. Type 'cancel' to leave.\n"))
+				continue
+			}
+			if lobby.Cancel(room) {
+				player.Conn.Write([]byte("You left the room.\n"))
+				return errLeftRoom
+			}
+		}
+	}
+}
+
+// chooseTrainer asks which AI strategy the server-side trainer should use
+func chooseTrainer(player *Player) (Strategy, error) {
+	var menu strings.Builder
+	menu.WriteString("Choose your opponent:\n")
+	for i, strategy := range strategies {
+		menu.WriteString(fmt.Sprintf("%d. AI Trainer (%s)\n", i+1, strategy.Name()))
+	}
+	menu.WriteString("Enter your choice: ")
+
+	for {
+		player.Conn.Write([]byte(menu.String()))
+		choice, err := player.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		index, err := strconv.Atoi(choice)
+		if err == nil && index >= 1 && index <= len(strategies) {
+			return strategies[index-1], nil
+		}
+		player.Conn.Write([]byte("Invalid choice. Try again.\n"))
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// testPlayer returns a player reading lines from the returned client end of a pipe, with
+// everything written to the player discarded
+func testPlayer(t *testing.T, name string) (*Player, net.Conn) {
+	t.Helper()
+	server, client := net.Pipe()
+	go io.Copy(io.Discard, client)
+	player := newPlayer(server)
+	player.Name = name
+	t.Cleanup(func() {
+		close(player.done)
+		client.Close()
+		server.Close()
+	})
+	return player, client
+}
+
+// readLine reads the next line of a player, failing the test if none comes within a second
+func readLine(t *testing.T, player *Player) string {
+	t.Helper()
+	lines := make(chan string, 1)
+	go func() {
+		line, _ := player.ReadLine()
+		lines <- line
+	}()
+	select {
+	case line := <-lines:
+		return line
+	case <-time.After(time.Second):
+		t.Fatalf("no line from %s", player.Name)
+		return ""
+	}
+}
+
+func TestWaitForOpponentLeavesInputToBattle(t *testing.T) {
+	for i := 0; i < 50; i++ {
+		host, client := testPlayer(t, "Ash")
+		room := &Room{ID: 1, Name: "test", Host: host, started: make(chan struct{}), finished: make(chan struct{})}
+		waited := make(chan error, 1)
+		go func() { waited <- waitForOpponent(host, room) }()
+
+		// The battle starts and asks the host for their first move, which the room must not take
+		close(room.started)
+		client.Write([]byte("1\n"))
+		if line := readLine(t, host); line != "1" {
+			t.Fatalf("battle read %q, want the host's answer 1", line)
+		}
+		close(room.finished)
+		if err := <-waited; err != nil {
+			t.Fatalf("waitForOpponent = %v, want nil after the battle", err)
+		}
+	}
+}
+
+func TestWaitForOpponentCancel(t *testing.T) {
+	host, client := testPlayer(t, "Ash")
+	room := lobby.Create(host, "test")
+	waited := make(chan error, 1)
+	go func() { waited <- waitForOpponent(host, room) }()
+
+	client.Write([]byte("hello\n"))
+	client.Write([]byte("cancel\n"))
+	select {
+	case err := <-waited:
+		if err != errLeftRoom {
+			t.Errorf("waitForOpponent = %v, want %v", err, errLeftRoom)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("waitForOpponent did not return after cancel")
+	}
+	if rooms, _ := lobby.OpenRooms(); len(rooms) != 0 {
+		t.Errorf("%d rooms still open after cancel", len(rooms))
+	}
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"os"
+	"sort"
+	"strconv"
+
+	"main/pokedex"
+)
+
+// MaxMoves is the number of moves a Pokémon knows in battle
+const MaxMoves = 4
+
+// Move is an attack a Pokémon can use in battle
+type Move struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Category string `json:"category"` // "physical" or "special"
+	Power    int    `json:"power"`
+	Accuracy int    `json:"accuracy"` // Hit chance in percent
+	PP       int    `json:"pp"`
+	Priority int    `json:"priority"` // Moves with higher priority go first regardless of Speed
+}
+
+// MoveSlot is a move known by a Pokémon together with its remaining PP
+type MoveSlot struct {
+	Move *Move
+	PP   int
+}
+
+// struggle is used when a Pokémon has no PP left on any of its moves
+var struggle = Move{Name: "Struggle", Category: "physical", Power: 50, Accuracy: 100}
+
+// IsSpecial reports whether the move uses Sp. Atk and Sp. Def
+func (m *Move) IsSpecial() bool {
+	return m.Category == "special"
+}
+
+// loadMoves loads the moves dataset from a JSON file
+func loadMoves(filename string) ([]Move, error) {
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load moves file: %v", err)
+	}
+	var moves []Move
+	if err := json.Unmarshal(file, &moves); err != nil {
+		return nil, fmt.Errorf("failed to parse moves file: %v", err)
+	}
+	return moves, nil
+}
+
+// learnset picks the moves a Pokémon knows: the strongest move of each of its types in the
+// category it is best at, the weakest move of each type, then Normal moves to fill the set.
+// The set depends only on the types and the better attacking stat, as moves.json has no
+// per-species learnsets, so species sharing their types and category know the same moves.
+func learnset(pokemon *pokedex.Pokemon, moves []Move) []*Move {
+	preferred := "physical"
+	if pokemon.Stats.SpAtk > pokemon.Stats.Attack {
+		preferred = "special"
+	}
+
+	// Moves of the Pokémon's better category first, strongest first within a category
+	sorted := make([]*Move, 0, len(moves))
+	for i := range moves {
+		sorted = append(sorted, &moves[i])
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if (sorted[i].Category == preferred) != (sorted[j].Category == preferred) {
+			return sorted[i].Category == preferred
+		}
+		return sorted[i].Power > sorted[j].Power
+	})
+
+	var set []*Move
+	known := make(map[string]bool)
+	learn := func(move *Move) {
+		if move != nil && !known[move.Name] && len(set) < MaxMoves {
+			set = append(set, move)
+			known[move.Name] = true
+		}
+	}
+
+	for _, t := range pokemon.Types {
+		for _, move := range sorted {
+			if move.Type == t {
+				learn(move)
+				break
+			}
+		}
+	}
+	for _, t := range pokemon.Types {
+		var weakest *Move
+		for _, move := range sorted {
+			if move.Type == t && (weakest == nil || move.Power < weakest.Power) {
+				weakest = move
+			}
+		}
+		learn(weakest)
+	}
+	for _, move := range sorted {
+		if move.Type == "normal" {
+			learn(move)
+		}
+	}
+	return set
+}
+
+// hasPP reports whether the Pokémon can still use any of its moves
+func hasPP(pokemon *BattlePokemon) bool {
+	for _, slot := range pokemon.Moves {
+		if slot.PP > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// chooseMove shows the Fight menu and returns the chosen move slot, or nil for Struggle
+func chooseMove(player *Player) (*MoveSlot, error) {
+	if !hasPP(player.Active) {
+		player.Conn.Write([]byte(fmt.Sprintf("%s has no moves left!\n", player.Active.Name)))
+		return nil, nil
+	}
+
+	for {
+		player.Conn.Write([]byte("Choose a move:\n"))
+		for i, slot := range player.Active.Moves {
+			player.Conn.Write([]byte(fmt.Sprintf("%d. %s (%s, %s, power %d, accuracy %d%%, PP %d/%d)\n",
+				i+1, slot.Move.Name, slot.Move.Type, slot.Move.Category, slot.Move.Power, slot.Move.Accuracy, slot.PP, slot.Move.PP)))
+		}
+		player.Conn.Write([]byte("Enter your choice: "))
+
+		choice, err := player.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+		index, err := strconv.Atoi(choice)
+		if err != nil || index < 1 || index > len(player.Active.Moves) {
+			player.Conn.Write([]byte("Invalid choice. Try again.\n"))
+			continue
+		}
+		slot := player.Active.Moves[index-1]
+		if slot.PP <= 0 {
+			player.Conn.Write([]byte(fmt.Sprintf("%s has no PP left!\n", slot.Move.Name)))
+			continue
+		}
+		return slot, nil
+	}
+}
+
+// randomMove picks a random move slot with PP left, or nil for Struggle
+func randomMove(pokemon *BattlePokemon) *MoveSlot {
+	var usable []*MoveSlot
+	for _, slot := range pokemon.Moves {
+		if slot.PP > 0 {
+			usable = append(usable, slot)
+		}
+	}
+	if len(usable) == 0 {
+		return nil
+	}
+	return usable[rand.Intn(len(usable))]
+}
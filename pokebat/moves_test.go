@@ -0,0 +1,45 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"main/pokedex"
+)
+
+func TestLearnset(t *testing.T) {
+	moves, err := loadMoves("../data/moves.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name  string
+		types []string
+		stats pokedex.Stats
+		want  []string
+	}{
+		{"Magikarp", []string{"water"}, pokedex.Stats{HP: 20, Attack: 10, Defense: 55, Speed: 80, SpAtk: 15, SpDef: 20},
+			[]string{"Surf", "Water Gun", "Hyper Voice", "Swift"}},
+		{"Lapras", []string{"water", "ice"}, pokedex.Stats{HP: 130, Attack: 85, Defense: 80, Speed: 60, SpAtk: 85, SpDef: 95},
+			[]string{"Waterfall", "Ice Punch", "Water Gun", "Ice Shard"}},
+		{"Gengar", []string{"ghost", "poison"}, pokedex.Stats{HP: 60, Attack: 65, Defense: 60, Speed: 110, SpAtk: 130, SpDef: 75},
+			[]string{"Shadow Ball", "Sludge Bomb", "Lick", "Poison Sting"}},
+	}
+	for _, test := range tests {
+		species := &pokedex.Pokemon{Name: test.name, Types: test.types, Stats: test.stats}
+		var got []string
+		for _, move := range learnset(species, moves) {
+			got = append(got, move.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s learns %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	// The set comes from the types and the better category, so Horsea knows the same moves as Magikarp
+	horsea := &pokedex.Pokemon{Name: "Horsea", Types: []string{"water"}, Stats: pokedex.Stats{Attack: 40, SpAtk: 70}}
+	magikarp := &pokedex.Pokemon{Name: "Magikarp", Types: []string{"water"}, Stats: pokedex.Stats{Attack: 10, SpAtk: 15}}
+	if a, b := learnset(horsea, moves), learnset(magikarp, moves); !reflect.DeepEqual(a, b) {
+		t.Errorf("Horsea and Magikarp learn different moves, want the same set for the same types and category")
+	}
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"main/pokedex"
+)
+
+// storeFile is the player store written by PokeCat, in the data directory
+var storeFile string
+
+// TeamSize is the number of Pokémon each player brings to a battle
+const TeamSize = 3
+
+// loginPlayer asks for the player's name until one with captured Pokémon is entered
+func loginPlayer(player *Player) ([]pokedex.Pokemon, error) {
+	for {
+		player.Conn.Write([]byte("Enter your PokeCat name: "))
+		name, err := player.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+
+		save, err := pokedex.LoadPlayer(storeFile, name)
+		if err != nil {
+			log.Printf("Failed to load Pokémon for %s: %v", name, err)
+			player.Conn.Write([]byte(fmt.Sprintf("Could not load your Pokémon: %v\n", err)))
+			continue
+		}
+		owned := save.Pokemons
+		if len(owned) == 0 {
+			player.Conn.Write([]byte("You have not caught any Pokémon yet. Go catch some in PokeCat first!\n"))
+			continue
+		}
+		if !lobby.Enter(name, player) {
+			player.Conn.Write([]byte(fmt.Sprintf("%s is already in the lobby.\n", name)))
+			continue
+		}
+		player.Name = name
+		return owned, nil
+	}
+}
+
+// chooseTeam lets a player pick their battle team from the Pokémon they own
+func chooseTeam(player *Player, owned []pokedex.Pokemon) error {
+	size := TeamSize
+	if len(owned) < size {
+		size = len(owned)
+	}
+
+	player.Conn.Write([]byte("Your Pokémon:\n"))
+	for i, pokemon := range owned {
+		player.Conn.Write([]byte(fmt.Sprintf("%d. %s (#%d)\n", i+1, pokemon.Name, pokemon.Number)))
+	}
+
+	for {
+		player.Conn.Write([]byte(fmt.Sprintf("Choose %d Pokémon by entering their list numbers (separated by space): ", size)))
+		choice, err := player.ReadLine()
+		if err != nil {
+			return err
+		}
+		choices := strings.Fields(choice)
+
+		if len(choices) != size {
+			player.Conn.Write([]byte(fmt.Sprintf("Invalid Pokémon selection. Please select exactly %d Pokémon.\n", size)))
+			continue
+		}
+
+		player.Team = nil
+		picked := make(map[int]bool)
+		for _, choice := range choices {
+			index, err := strconv.Atoi(choice)
+			if err != nil || index < 1 || index > len(owned) {
+				player.Conn.Write([]byte(fmt.Sprintf("%s is not one of your Pokémon. Please try again.\n", choice)))
+				player.Team = nil
+				break
+			}
+			if picked[index] {
+				player.Conn.Write([]byte(fmt.Sprintf("You already picked %s. Please try again.\n", owned[index-1].Name)))
+				player.Team = nil
+				break
+			}
+			picked[index] = true
+			player.Team = append(player.Team, lookupPokemon(owned[index-1]))
+		}
+
+		if len(player.Team) == size {
+			return nil
+		}
+	}
+}
+
+// lookupPokemon returns the pokedex entry for a captured Pokémon, falling back to the saved copy
+func lookupPokemon(captured pokedex.Pokemon) *pokedex.Pokemon {
+	if pokemon, ok := dex.ByNumber(captured.Number); ok {
+		return pokemon
+	}
+	return &captured
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// errBattleAborted is returned to a player's pending choice when the battle ends early
+var errBattleAborted = errors.New("battle aborted")
+
+// MatchState is the stage a battle is in
+type MatchState int
+
+const (
+	MatchInProgress MatchState = iota // Players are choosing and resolving turns
+	MatchFinished                     // One player has no Pokémon left
+	MatchAborted                      // A player left before the match was decided
+)
+
+// MatchStats is what a player achieved during a match
+type MatchStats struct {
+	DamageDealt int
+	KOs         int
+}
+
+// Battle is a match between the two players of a room
+type Battle struct {
+	Room    *Room
+	Players [2]*Player
+	State   MatchState
+	Turn    int
+	Winner  *Player
+	Stats   map[*Player]*MatchStats
+	abort   chan struct{} // Closed to interrupt pending choices when a player leaves
+}
+
+// Action is what a player chose to do this turn
+type Action struct {
+	Player  *Player
+	Target  *Player
+	Pokemon *BattlePokemon // Active Pokémon when the action was chosen
+	Switch  *BattlePokemon // Pokémon to switch to, nil when using a move
+	Slot    *MoveSlot      // Move to use, nil for Struggle
+	speed   int            // Speed of the acting Pokémon at resolution time
+	tie     float64        // Random tiebreaker between equally fast Pokémon
+}
+
+// Move returns the move the action uses
+func (a *Action) Move() *Move {
+	if a.Slot == nil {
+		return &struggle
+	}
+	return a.Slot.Move
+}
+
+// runBattle plays matches between the host and guest of a room until they stop asking for a rematch
+func runBattle(room *Room) {
+	defer close(room.finished)
+
+	battle := &Battle{
+		Room:    room,
+		Players: [2]*Player{room.Host, room.Guest},
+	}
+	defer func() {
+		for _, player := range battle.Players {
+			player.abort = nil
+		}
+	}()
+
+	for {
+		battle.reset()
+		battle.play()
+		if battle.State == MatchAborted {
+			battle.broadcast("A player left the battle. The match is over.\n")
+			return
+		}
+
+		battle.announceResult()
+		rematch, err := battle.askRematch()
+		if err != nil {
+			log.Printf("Rematch prompt in room %d ended early: %v", room.ID, err)
+			battle.broadcast("A player left. Returning to the lobby.\n")
+			return
+		}
+		if !rematch {
+			battle.broadcast("Returning to the lobby.\n")
+			return
+		}
+	}
+}
+
+// reset gives both players fresh teams and starts a new match
+func (b *Battle) reset() {
+	b.State = MatchInProgress
+	b.Turn = 0
+	b.Winner = nil
+	b.Stats = make(map[*Player]*MatchStats)
+	b.abort = make(chan struct{})
+	for _, player := range b.Players {
+		resetTeam(player, moveList)
+		b.Stats[player] = &MatchStats{}
+		player.abort = b.abort
+	}
+}
+
+// play runs turns until the match is finished or aborted
+func (b *Battle) play() {
+	fmt.Printf("Battle started in room %d: %s vs %s\n", b.Room.ID, b.Players[0].Name, b.Players[1].Name)
+	host, guest := b.Players[0], b.Players[1]
+	host.Conn.Write([]byte(fmt.Sprintf("%s, prepare for battle against %s!\n", host.Name, guest.Name)))
+	guest.Conn.Write([]byte(fmt.Sprintf("%s, prepare for battle against %s!\n", guest.Name, host.Name)))
+
+	for b.State == MatchInProgress {
+		b.Turn++
+		actions, err := b.collectActions()
+		if err == nil {
+			b.broadcast(b.resolveTurn(actions))
+			if !b.Over() {
+				err = b.replaceFainted()
+			}
+		}
+		if err != nil {
+			log.Printf("Battle in room %d ended early: %v", b.Room.ID, err)
+			b.State = MatchAborted
+			return
+		}
+
+		if b.Over() {
+			b.State = MatchFinished
+			if !checkAllPokemonFainted(b.Players[0]) {
+				b.Winner = b.Players[0]
+			} else if !checkAllPokemonFainted(b.Players[1]) {
+				b.Winner = b.Players[1]
+			}
+		} else if b.automated() {
+			time.Sleep(1 * time.Second) // Add delay so players can follow an automatic battle
+		}
+	}
+	fmt.Printf("Battle in room %d finished after %d turns\n", b.Room.ID, b.Turn)
+}
+
+// Over reports whether either player has no Pokémon left to battle
+func (b *Battle) Over() bool {
+	return checkAllPokemonFainted(b.Players[0]) || checkAllPokemonFainted(b.Players[1])
+}
+
+// automated reports whether every player's decisions are made by an AI strategy
+func (b *Battle) automated() bool {
+	for _, player := range b.Players {
+		if player.Strategy == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// opponent returns the other player in the battle
+func (b *Battle) opponent(player *Player) *Player {
+	if b.Players[0] == player {
+		return b.Players[1]
+	}
+	return b.Players[0]
+}
+
+// broadcast sends the same message to both players
+func (b *Battle) broadcast(message string) {
+	for _, player := range b.Players {
+		player.Conn.Write([]byte(message))
+	}
+}
+
+// forBothPlayers runs fn for both players at the same time and waits for both to finish.
+// The first error interrupts the other player's pending choice.
+func (b *Battle) forBothPlayers(fn func(player *Player) error) error {
+	errs := make(chan error, len(b.Players))
+	for _, player := range b.Players {
+		go func(player *Player) {
+			errs <- fn(player)
+		}(player)
+	}
+
+	var firstErr error
+	for range b.Players {
+		if err := <-errs; err != nil && firstErr == nil {
+			firstErr = err
+			close(b.abort)
+		}
+	}
+	return firstErr
+}
+
+// collectActions asks both players for their action at the same time, so neither sees the
+// other's choice before making their own
+func (b *Battle) collectActions() ([]*Action, error) {
+	actions := make([]*Action, len(b.Players))
+	err := b.forBothPlayers(func(player *Player) error {
+		action, err := b.chooseAction(player)
+		if err != nil {
+			return err
+		}
+		for i, p := range b.Players {
+			if p == player {
+				actions[i] = action
+			}
+		}
+		return nil
+	})
+	return actions, err
+}
+
+// chooseAction shows the action menu to a player and returns their choice
+func (b *Battle) chooseAction(player *Player) (*Action, error) {
+	if player.Strategy != nil {
+		return player.Strategy.ChooseAction(b, player), nil
+	}
+	opponent := b.opponent(player)
+	action := newAction(b, player)
+
+	for {
+		player.Conn.Write([]byte(fmt.Sprintf("Turn %d\nActive Pokémon: %s (HP %d/%d)\nOpponent: %s (HP %d/%d)\n",
+			b.Turn, player.Active.Name, player.Active.HP, player.Active.MaxHP,
+			opponent.Active.Name, opponent.Active.HP, opponent.Active.MaxHP)))
+		player.Conn.Write([]byte("Choose action:\n1. Fight\n2. Switch Pokémon\nEnter your choice: "))
+
+		choice, err := player.ReadLine()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read player choice: %v", err)
+		}
+
+		switch choice {
+		case "1":
+			action.Slot, err = chooseMove(player)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read move choice: %v", err)
+			}
+		case "2":
+			action.Switch, err = chooseSwitch(player)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read Pokémon switch choice: %v", err)
+			}
+			if action.Switch == nil {
+				continue
+			}
+		default:
+			player.Conn.Write([]byte("Invalid choice. Try again.\n"))
+			continue
+		}
+
+		player.Conn.Write([]byte("Waiting for your opponent...\n"))
+		return action, nil
+	}
+}
+
+// resolveTurn carries out both actions and returns the combined turn summary.
+// Switches happen first, then moves by priority and current Speed, with Speed ties broken at random.
+func (b *Battle) resolveTurn(actions []*Action) string {
+	var summary strings.Builder
+	summary.WriteString(fmt.Sprintf("--- Turn %d ---\n", b.Turn))
+
+	var moves []*Action
+	for _, action := range actions {
+		if action.Switch == nil {
+			moves = append(moves, action)
+			continue
+		}
+		action.Player.Active = action.Switch
+		summary.WriteString(fmt.Sprintf("%s switched %s for %s!\n", action.Player.Name, action.Pokemon.Name, action.Switch.Name))
+	}
+
+	for _, action := range moves {
+		action.speed = action.Player.Active.Speed()
+		action.tie = rand.Float64()
+	}
+	sort.Slice(moves, func(i, j int) bool {
+		if moves[i].Move().Priority != moves[j].Move().Priority {
+			return moves[i].Move().Priority > moves[j].Move().Priority
+		}
+		if moves[i].speed != moves[j].speed {
+			return moves[i].speed > moves[j].speed
+		}
+		return moves[i].tie > moves[j].tie
+	})
+
+	for _, action := range moves {
+		if action.Player.Active != action.Pokemon || action.Pokemon.Fainted() {
+			continue
+		}
+		b.useMove(&summary, action.Player, action.Target, action.Slot)
+	}
+	return summary.String()
+}
+
+// useMove spends PP on a move, rolls for accuracy and applies its damage to the defender.
+// A nil slot means the attacker has run out of PP and uses Struggle.
+func (b *Battle) useMove(summary *strings.Builder, attacker *Player, defender *Player, slot *MoveSlot) {
+	move := &struggle
+	if slot != nil {
+		move = slot.Move
+		slot.PP--
+	}
+
+	summary.WriteString(fmt.Sprintf("%s's %s used %s!\n", attacker.Name, attacker.Active.Name, move.Name))
+
+	if rand.Intn(100) >= move.Accuracy {
+		summary.WriteString("The attack missed!\n")
+		return
+	}
+
+	result := calculateDamage(attacker.Active, defender.Active, move)
+	battleLog.Println(result.Breakdown())
+	dealt := defender.Active.HP
+	defender.Active.TakeDamage(result.Damage)
+	b.Stats[attacker].DamageDealt += dealt - defender.Active.HP
+	fmt.Printf("%s dealt %d damage!\n", attacker.Name, result.Damage)
+
+	if result.Critical && result.Damage > 0 {
+		summary.WriteString("A critical hit!\n")
+	}
+	if label := effectivenessLabel(result.Type); label != "" {
+		summary.WriteString(label + "\n")
+	}
+	summary.WriteString(fmt.Sprintf("%s's %s took %d damage (HP %d/%d).\n",
+		defender.Name, defender.Active.Name, result.Damage, defender.Active.HP, defender.Active.MaxHP))
+	if defender.Active.Fainted() {
+		b.Stats[attacker].KOs++
+		summary.WriteString(fmt.Sprintf("%s's %s fainted!\n", defender.Name, defender.Active.Name))
+	}
+}
+
+// replaceFainted has every player whose active Pokémon fainted send out a new one
+func (b *Battle) replaceFainted() error {
+	return b.forBothPlayers(func(player *Player) error {
+		if !player.Active.Fainted() {
+			return nil
+		}
+		var next *BattlePokemon
+		if player.Strategy != nil {
+			next = player.Strategy.ChooseReplacement(b, player)
+		} else {
+			player.Conn.Write([]byte("Your Pokémon fainted!\n"))
+			var err error
+			if next, err = chooseSwitch(player); err != nil {
+				return fmt.Errorf("failed to read Pokémon switch choice: %v", err)
+			}
+		}
+		if next == nil {
+			next = firstHealthy(player)
+		}
+		player.Active = next
+		b.opponent(player).Conn.Write([]byte(fmt.Sprintf("%s sent out %s!\n", player.Name, next.Name)))
+		player.Conn.Write([]byte(fmt.Sprintf("Go, %s!\n", next.Name)))
+		return nil
+	})
+}
+
+// announceResult tells both players who won and sends the match results
+func (b *Battle) announceResult() {
+	for _, player := range b.Players {
+		switch b.Winner {
+		case player:
+			player.Conn.Write([]byte("You win!\n"))
+		case nil:
+			player.Conn.Write([]byte("It's a draw!\n"))
+		default:
+			player.Conn.Write([]byte("You lose!\n"))
+		}
+	}
+	b.broadcast(b.Results())
+}
+
+// Results summarizes the finished match
+func (b *Battle) Results() string {
+	var results strings.Builder
+	results.WriteString("=== Match results ===\n")
+	if b.Winner != nil {
+		results.WriteString(fmt.Sprintf("Winner: %s\n", b.Winner.Name))
+	} else {
+		results.WriteString("Winner: none\n")
+	}
+	results.WriteString(fmt.Sprintf("Turns: %d\n", b.Turn))
+	for _, player := range b.Players {
+		stats := b.Stats[player]
+		results.WriteString(fmt.Sprintf("%s: %d damage dealt, %d KOs, %d/%d Pokémon left\n",
+			player.Name, stats.DamageDealt, stats.KOs, remainingPokemon(player), len(player.Pokemons)))
+	}
+	return results.String()
+}
+
+// askRematch asks both players whether they want a rematch, which only happens if both agree
+func (b *Battle) askRematch() (bool, error) {
+	var mutex sync.Mutex
+	agreed := 0
+	err := b.forBothPlayers(func(player *Player) error {
+		if player.AI {
+			mutex.Lock()
+			agreed++
+			mutex.Unlock()
+			return nil
+		}
+		for {
+			player.Conn.Write([]byte("Rematch?\n1. Rematch\n2. Return to lobby\nEnter your choice: "))
+			choice, err := player.ReadLine()
+			if err != nil {
+				return err
+			}
+			switch choice {
+			case "1":
+				player.Conn.Write([]byte("Waiting for your opponent...\n"))
+				mutex.Lock()
+				agreed++
+				mutex.Unlock()
+				return nil
+			case "2":
+				return nil
+			default:
+				player.Conn.Write([]byte("Invalid choice. Try again.\n"))
+			}
+		}
+	})
+	return agreed == len(b.Players), err
+}
+
+// remainingPokemon counts the Pokémon of a player that can still battle
+func remainingPokemon(player *Player) int {
+	count := 0
+	for _, pokemon := range player.Pokemons {
+		if !pokemon.Fainted() {
+			count++
+		}
+	}
+	return count
+}
+
+// chooseSwitch asks the player which healthy benched Pokémon to switch to,
+// returning nil if there is none
+func chooseSwitch(player *Player) (*BattlePokemon, error) {
+	validChoices := make(map[int]*BattlePokemon)
+	var menu strings.Builder
+	menu.WriteString("Choose a Pokémon to switch to:\n")
+	for i, pokemon := range player.Pokemons {
+		if pokemon != player.Active && !pokemon.Fainted() {
+			menu.WriteString(fmt.Sprintf("%d. %s (HP %d/%d)\n", i, pokemon.Name, pokemon.HP, pokemon.MaxHP))
+			validChoices[i] = pokemon
+		}
+	}
+
+	if len(validChoices) == 0 {
+		player.Conn.Write([]byte("No valid Pokémon to switch to!\n"))
+		return nil, nil
+	}
+
+	for {
+		player.Conn.Write([]byte(menu.String()))
+		choice, err := player.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+
+		selectedIndex := -1
+		fmt.Sscanf(choice, "%d", &selectedIndex)
+		if selectedPokemon, ok := validChoices[selectedIndex]; ok {
+			return selectedPokemon, nil
+		}
+		player.Conn.Write([]byte("Invalid choice. Try again.\n"))
+	}
+}
+
+// firstHealthy returns the first Pokémon of the team that can still battle
+func firstHealthy(player *Player) *BattlePokemon {
+	for _, pokemon := range player.Pokemons {
+		if !pokemon.Fainted() {
+			return pokemon
+		}
+	}
+	return nil
+}
+
+func checkAllPokemonFainted(player *Player) bool {
+	return remainingPokemon(player) == 0
+}
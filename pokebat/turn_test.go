@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"main/pokedex"
+)
+
+var (
+	tackle      = &Move{Name: "Tackle", Type: "normal", Category: "physical", Power: 40, Accuracy: 100, PP: 35}
+	quickAttack = &Move{Name: "Quick Attack", Type: "normal", Category: "physical", Power: 40, Accuracy: 100, PP: 30, Priority: 1}
+)
+
+// battler returns a level 50 Normal type with plenty of HP and the given Speed
+func battler(name string, speed int) *BattlePokemon {
+	return &BattlePokemon{
+		Name:  name,
+		Types: []string{"normal"},
+		Level: BattleLevel,
+		MaxHP: 1000,
+		HP:    1000,
+		Stats: pokedex.Stats{HP: 1000, Attack: 50, Defense: 50, Speed: speed, SpAtk: 50, SpDef: 50},
+	}
+}
+
+// testBattle returns a battle between two players, each with the given Pokémon and the first one active
+func testBattle(first []*BattlePokemon, second []*BattlePokemon) *Battle {
+	a := &Player{Name: "Ash", Pokemons: first, Active: first[0]}
+	b := &Player{Name: "Gary", Pokemons: second, Active: second[0]}
+	return &Battle{Players: [2]*Player{a, b}, Turn: 1, Stats: map[*Player]*MatchStats{a: {}, b: {}}}
+}
+
+// useAction returns an action using a move
+func useAction(b *Battle, player *Player, move *Move) *Action {
+	action := newAction(b, player)
+	action.Slot = &MoveSlot{Move: move, PP: move.PP}
+	return action
+}
+
+// order returns the names of the players in the order their moves appear in a turn summary
+func order(summary string) []string {
+	var names []string
+	for _, line := range strings.Split(summary, "\n") {
+		if name, _, found := strings.Cut(line, "'s "); found && strings.Contains(line, " used ") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestResolveTurnOrder(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(ash, gary *BattlePokemon)
+		ash   *Move
+		gary  *Move
+		want  string // Players in the order they move, a fainted one missing
+	}{
+		{"faster first", func(ash, gary *BattlePokemon) { ash.Stats.Speed = 100 }, tackle, tackle, "Ash Gary"},
+		{"slower second", func(ash, gary *BattlePokemon) { gary.Stats.Speed = 100 }, tackle, tackle, "Gary Ash"},
+		{"priority beats speed", func(ash, gary *BattlePokemon) { gary.Stats.Speed = 100 }, quickAttack, tackle, "Ash Gary"},
+		{"speed stage", func(ash, gary *BattlePokemon) { ash.Stages.Speed = 2; gary.Stats.Speed = 80 }, tackle, tackle, "Ash Gary"},
+		{"paralysis halves speed", func(ash, gary *BattlePokemon) {
+			ash.Stats.Speed = 100
+			ash.Status = "paralysis"
+			gary.Stats.Speed = 80
+		}, tackle, tackle, "Gary Ash"},
+		{"fainted before moving", func(ash, gary *BattlePokemon) { ash.Stats.Speed = 100; gary.HP = 1 }, tackle, tackle, "Ash"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ash, gary := battler("Rattata", 50), battler("Pidgey", 50)
+			test.setup(ash, gary)
+			b := testBattle([]*BattlePokemon{ash}, []*BattlePokemon{gary})
+			summary := b.resolveTurn([]*Action{useAction(b, b.Players[0], test.ash), useAction(b, b.Players[1], test.gary)})
+			if got := strings.Join(order(summary), " "); got != test.want {
+				t.Errorf("moves went %q, want %q:\n%s", got, test.want, summary)
+			}
+		})
+	}
+}
+
+func TestResolveTurnSwitchFirst(t *testing.T) {
+	rattata, raticate, pidgey := battler("Rattata", 50), battler("Raticate", 200), battler("Pidgey", 10)
+	b := testBattle([]*BattlePokemon{rattata, raticate}, []*BattlePokemon{pidgey})
+	ash, gary := b.Players[0], b.Players[1]
+	swap := newAction(b, ash)
+	swap.Switch = raticate
+
+	// Gary's move is listed first but the switch still happens before it, and the attack hits Raticate
+	summary := b.resolveTurn([]*Action{useAction(b, gary, tackle), swap})
+	lines := strings.Split(summary, "\n")
+	if !strings.HasPrefix(lines[1], "Ash switched Rattata for Raticate!") {
+		t.Errorf("switch is not the first thing in the turn:\n%s", summary)
+	}
+	if ash.Active != raticate || raticate.HP == raticate.MaxHP || rattata.HP != rattata.MaxHP {
+		t.Errorf("the attack did not hit the Pokémon switched in:\n%s", summary)
+	}
+	if got := order(summary); len(got) != 1 || got[0] != "Gary" {
+		t.Errorf("moves %v, want only Gary's", got)
+	}
+}
+
+func TestResolveTurnSpeedTie(t *testing.T) {
+	first := make(map[string]int)
+	for i := 0; i < 200; i++ {
+		b := testBattle([]*BattlePokemon{battler("Rattata", 80)}, []*BattlePokemon{battler("Pidgey", 80)})
+		summary := b.resolveTurn([]*Action{useAction(b, b.Players[0], tackle), useAction(b, b.Players[1], tackle)})
+		first[order(summary)[0]]++
+	}
+	if first["Ash"] == 0 || first["Gary"] == 0 {
+		t.Errorf("with equal Speed Ash went first %d times and Gary %d times in 200 turns, want both", first["Ash"], first["Gary"])
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"main/pokedex"
+)
+
+func TestTypeChartCoversEveryType(t *testing.T) {
+	for _, attack := range pokedex.KnownTypes {
+		if _, ok := typeChart[attack]; !ok {
+			t.Errorf("type chart has no row for %s", attack)
+		}
+	}
+	for attack, row := range typeChart {
+		if !slices.Contains(pokedex.KnownTypes, attack) {
+			t.Errorf("type chart has a row for unknown type %s", attack)
+		}
+		for defend, multiplier := range row {
+			if !slices.Contains(pokedex.KnownTypes, defend) {
+				t.Errorf("%s has a multiplier against unknown type %s", attack, defend)
+			}
+			if multiplier != 0 && multiplier != 0.5 && multiplier != 2 {
+				t.Errorf("%s against %s is %v, want 0, 0.5 or 2", attack, defend, multiplier)
+			}
+		}
+	}
+
+	dex, err := pokedex.Load("../data/pokedex.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, species := range dex.All() {
+		for _, defend := range species.Types {
+			if _, ok := typeChart[defend]; !ok {
+				t.Errorf("%s has type %s missing from the chart", species.Name, defend)
+			}
+		}
+	}
+}
+
+func TestTypeMultiplier(t *testing.T) {
+	tests := []struct {
+		attack string
+		defend []string
+		want   float64
+	}{
+		{"fire", []string{"grass"}, 2},
+		{"fire", []string{"grass", "poison"}, 2},
+		{"fire", []string{"grass", "bug"}, 4},
+		{"fire", []string{"water", "rock"}, 0.25},
+		{"fire", []string{"water", "grass"}, 1},
+		{"electric", []string{"water", "flying"}, 4},
+		{"grass", []string{"fire", "flying"}, 0.25},
+		{"ground", []string{"flying"}, 0},
+		{"ground", []string{"electric", "flying"}, 0},
+		{"normal", []string{"ghost", "poison"}, 0},
+		{"ghost", []string{"normal"}, 0},
+		{"electric", []string{"ground"}, 0},
+		{"psychic", []string{"dark"}, 0},
+		{"dragon", []string{"fairy"}, 0},
+		{"fighting", []string{"normal", "ice"}, 4},
+		{"water", []string{"normal"}, 1},
+		{"ice", []string{"dragon", "flying"}, 4},
+	}
+	for _, test := range tests {
+		if got := typeMultiplier(test.attack, test.defend); got != test.want {
+			t.Errorf("%s against %v = %v, want %v", test.attack, test.defend, got, test.want)
+		}
+	}
+}
+
+func TestEffectivenessLabel(t *testing.T) {
+	tests := []struct {
+		multiplier float64
+		want       string
+	}{
+		{0, "It has no effect..."},
+		{0.25, "It's not very effective..."},
+		{0.5, "It's not very effective..."},
+		{1, ""},
+		{2, "It's super effective!"},
+		{4, "It's super effective!"},
+	}
+	for _, test := range tests {
+		if got := effectivenessLabel(test.multiplier); got != test.want {
+			t.Errorf("effectivenessLabel(%v) = %q, want %q", test.multiplier, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// EventKind tells what happened to a wild Pokémon
+type EventKind int
+
+const (
+	Spawned   EventKind = iota // The Pokémon appeared on the grid
+	Despawned                  // The Pokémon left without being caught
+	Caught                     // A player caught the Pokémon
+)
+
+// WorldEvent is a change to the wild Pokémon on the grid
+type WorldEvent struct {
+	Kind    EventKind
+	Pokemon WildPokemon
+	Player  string // Player who caught the Pokémon, for Caught events
+}
+
+// radarBuffer is the number of events a subscriber can fall behind before new events are dropped
+const radarBuffer = 32
+
+// Subscription receives the events published after it subscribed
+type Subscription struct {
+	Events  <-chan WorldEvent
+	events  chan WorldEvent
+	dropped atomic.Int64 // Events dropped because the subscriber was too slow
+}
+
+// Dropped returns the number of events dropped since the last call
+func (s *Subscription) Dropped() int64 {
+	return s.dropped.Swap(0)
+}
+
+// Broadcaster fans world events out to every subscriber. Publishing never blocks: a subscriber
+// whose buffer is full misses the event instead of holding up the generator.
+type Broadcaster struct {
+	mutex       sync.Mutex
+	subscribers map[*Subscription]struct{}
+}
+
+// NewBroadcaster returns a broadcaster with no subscribers
+func NewBroadcaster() *Broadcaster {
+	return &Broadcaster{subscribers: make(map[*Subscription]struct{})}
+}
+
+// Subscribe starts delivering events to a new subscription
+func (b *Broadcaster) Subscribe() *Subscription {
+	events := make(chan WorldEvent, radarBuffer)
+	sub := &Subscription{Events: events, events: events}
+	b.mutex.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mutex.Unlock()
+	return sub
+}
+
+// Unsubscribe stops delivering events and closes the subscription's channel
+func (b *Broadcaster) Unsubscribe(sub *Subscription) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.subscribers[sub]; ok {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
+
+// Publish sends an event to every subscriber that has room for it
+func (b *Broadcaster) Publish(event WorldEvent) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	for sub := range b.subscribers {
+		select {
+		case sub.events <- event:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+// runRadar tells a player about the events within their radar range until they disconnect
+func runRadar(player *Player, sub *Subscription) {
+	for event := range sub.Events {
+		mutex.Lock()
+		radius, x, y := player.Radar, player.X, player.Y
+		mutex.Unlock()
+		if radius == 0 {
+			sub.Dropped()
+			continue
+		}
+		if missed := sub.Dropped(); missed > 0 {
+			player.Conn.Write([]byte(fmt.Sprintf("Radar: missed %d events\n", missed)))
+		}
+
+		pokemon := event.Pokemon
+		distance := abs(pokemon.X-x) + abs(pokemon.Y-y)
+		if distance > radius {
+			continue
+		}
+		switch event.Kind {
+		case Spawned:
+			player.Conn.Write([]byte(fmt.Sprintf("Radar: a wild %s appeared at (%d, %d), distance %d\n", pokemon.Name, pokemon.X, pokemon.Y, distance)))
+		case Despawned:
+			player.Conn.Write([]byte(fmt.Sprintf("Radar: %s at (%d, %d) disappeared\n", pokemon.Name, pokemon.X, pokemon.Y)))
+		case Caught:
+			if event.Player != player.Name {
+				player.Conn.Write([]byte(fmt.Sprintf("Radar: %s caught %s at (%d, %d)\n", event.Player, pokemon.Name, pokemon.X, pokemon.Y)))
+			}
+		}
+	}
+}
+
+// abs returns the absolute value of an int
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
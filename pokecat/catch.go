@@ -0,0 +1,329 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+
+	"main/pokedex"
+)
+
+// Ball is a kind of Poké Ball a player can throw
+type Ball struct {
+	Name  string  // Name used in commands and in the config
+	Label string  // Name shown to players
+	Bonus float64 // Catch rate multiplier, 0 for a ball that never fails
+}
+
+// Balls lists the balls in the game, from the most common to the rarest
+var Balls = []Ball{
+	{Name: "poke", Label: "Poké Ball", Bonus: 1},
+	{Name: "great", Label: "Great Ball", Bonus: 1.5},
+	{Name: "ultra", Label: "Ultra Ball", Bonus: 2},
+	{Name: "master", Label: "Master Ball"},
+}
+
+// findBall returns the ball with the given name
+func findBall(name string) (Ball, bool) {
+	for _, ball := range Balls {
+		if ball.Name == name {
+			return ball, true
+		}
+	}
+	return Ball{}, false
+}
+
+// fallbackCatchRate is the catch rate of species whose catch rate is missing from the dex. Like the
+// spawn weights it falls with base EXP: a species with 50 base EXP or less gets the highest rate of 255,
+// and the rate shrinks with the 1.5 power of the EXP above that, down to the lowest rate of 3.
+func fallbackCatchRate(pokemon *pokedex.Pokemon) int {
+	if pokemon.Exp <= 0 {
+		return 255
+	}
+	rate := 255 * math.Pow(50/float64(pokemon.Exp), 1.5)
+	return max(3, min(255, int(rate)))
+}
+
+// encounterLevel is the level of wild Pokémon and of the player's Pokémon in encounter battles
+const encounterLevel = 50
+
+// Encounter is a player facing one wild Pokémon
+type Encounter struct {
+	Pokemon WildPokemon
+	HP      int // Current HP, lowered by battling
+	MaxHP   int
+}
+
+// newEncounter starts an encounter with a wild Pokémon at full HP
+func newEncounter(pokemon WildPokemon) *Encounter {
+	maxHP := pokemon.Stats.AtLevel(encounterLevel).HP
+	return &Encounter{Pokemon: pokemon, HP: maxHP, MaxHP: maxHP}
+}
+
+// CatchChance returns the probability of a ball catching the Pokémon, following the main series
+// formula: a low catch rate makes a species hard to catch, and a low HP makes it easier
+func (e *Encounter) CatchChance(ball Ball) float64 {
+	if ball.Bonus == 0 {
+		return 1
+	}
+	rate := e.Pokemon.CatchRate
+	if rate == 0 {
+		rate = fallbackCatchRate(&e.Pokemon.Pokemon)
+	}
+	a := float64(3*e.MaxHP-2*e.HP) * float64(rate) * ball.Bonus / float64(3*e.MaxHP)
+	return min(1, a/255)
+}
+
+// attackDamage returns the damage of a 40 power attack from the player's Pokémon, with the 85-100% random roll.
+// Both Pokémon fight with their stats at encounterLevel.
+func attackDamage(attacker *pokedex.Pokemon, defender *pokedex.Pokemon) int {
+	attack := attacker.Stats.AtLevel(encounterLevel).Attack
+	defense := defender.Stats.AtLevel(encounterLevel).Defense
+	base := (2*encounterLevel/5+2)*40*attack/defense/50 + 2
+	return max(1, base*(85+rand.Intn(16))/100)
+}
+
+// wildOnTile returns the Pokémon that has been on the player's tile the longest, if any
+func wildOnTile(player *Player) (WildPokemon, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	pokemons := world.At(player.X, player.Y)
+	if len(pokemons) == 0 {
+		return WildPokemon{}, false
+	}
+	return pokemons[0], true
+}
+
+// collectionFull reports whether the player holds max_pokemon_capacity Pokémon, and tells them
+func collectionFull(player *Player) bool {
+	mutex.Lock()
+	count := len(player.Pokemons)
+	mutex.Unlock()
+	if count < config.MaxPokemonCapacity {
+		return false
+	}
+	player.Conn.Write([]byte(fmt.Sprintf("Your collection is full with %d Pokémon, you can't catch any more.\n", count)))
+	return true
+}
+
+// encounter lets the player throw balls at, battle or run from the Pokémon on their tile.
+// It returns false if the player disconnected.
+func encounter(player *Player, scanner *bufio.Scanner) bool {
+	pokemon, ok := wildOnTile(player)
+	if !ok || collectionFull(player) {
+		return true
+	}
+	e := newEncounter(pokemon)
+	player.Conn.Write([]byte(fmt.Sprintf("A wild %s appeared!\n", pokemon.Name)))
+
+	for {
+		player.Conn.Write([]byte(fmt.Sprintf("%s HP %d/%d. %s\n", pokemon.Name, e.HP, e.MaxHP, bag(player))))
+		player.Conn.Write([]byte("Choose: 'throw [poke|great|ultra|master]', 'battle' or 'run'\n"))
+		if !scanner.Scan() {
+			return false
+		}
+		args := strings.Fields(scanner.Text())
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "throw":
+			name := "poke"
+			if len(args) > 1 {
+				name = args[1]
+			}
+			ball, ok := findBall(name)
+			if !ok {
+				player.Conn.Write([]byte(fmt.Sprintf("There is no %s ball.\n", name)))
+				continue
+			}
+			if throwBall(player, e, ball) {
+				return true
+			}
+		case "battle":
+			if battleTurn(player, e) {
+				return true
+			}
+		case "run":
+			player.Conn.Write([]byte("Got away safely!\n"))
+			return true
+		default:
+			player.Conn.Write([]byte("Invalid command. Try again.\n"))
+		}
+	}
+}
+
+// autoEncounter throws the player's most common balls at the Pokémon on their tile until it is caught,
+// it flees or the balls run out. Master Balls are kept for the player to use.
+func autoEncounter(player *Player) {
+	pokemon, ok := wildOnTile(player)
+	if !ok || collectionFull(player) {
+		return
+	}
+	e := newEncounter(pokemon)
+	player.Conn.Write([]byte(fmt.Sprintf("A wild %s appeared!\n", pokemon.Name)))
+	for {
+		ball, ok := commonestBall(player)
+		if !ok {
+			player.Conn.Write([]byte("Auto mode: you are out of balls.\n"))
+			return
+		}
+		if throwBall(player, e, ball) {
+			return
+		}
+	}
+}
+
+// commonestBall returns the first ball in Balls the player still has, other than a Master Ball
+func commonestBall(player *Player) (Ball, bool) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, ball := range Balls {
+		if ball.Bonus > 0 && player.Balls[ball.Name] > 0 {
+			return ball, true
+		}
+	}
+	return Ball{}, false
+}
+
+// throwBall throws one of the player's balls and reports whether the encounter is over
+func throwBall(player *Player, e *Encounter, ball Ball) bool {
+	pokemon := e.Pokemon
+	mutex.Lock()
+	if player.Balls[ball.Name] == 0 {
+		mutex.Unlock()
+		player.Conn.Write([]byte(fmt.Sprintf("You have no %s left.\n", ball.Label)))
+		return false
+	}
+	if _, ok := world.Get(pokemon.ID); !ok {
+		mutex.Unlock()
+		player.Conn.Write([]byte(fmt.Sprintf("%s is gone.\n", pokemon.Name)))
+		return true
+	}
+	player.Balls[ball.Name]--
+	if rand.Float64() >= e.CatchChance(ball) {
+		mutex.Unlock()
+		player.Conn.Write([]byte(fmt.Sprintf("Oh no! %s broke free from the %s.\n", pokemon.Name, ball.Label)))
+		return flee(player, e)
+	}
+
+	// Player catches the Pokémon, remove it from the world and from the despawn schedule
+	player.Pokemons = append(player.Pokemons, &pokemon.Pokemon)
+	world.Remove(pokemon.ID)
+	despawns.Cancel(pokemon.ID)
+	mutex.Unlock()
+
+	fmt.Printf("Player %s caught Pokémon: %s\n", player.Name, pokemon.Name)
+	player.Conn.Write([]byte(fmt.Sprintf("Gotcha! You caught %s with a %s.\n", pokemon.Name, ball.Label)))
+	events.Publish(WorldEvent{Kind: Caught, Pokemon: pokemon, Player: player.Name})
+	if err := savePlayer(player); err != nil {
+		log.Printf("Failed to save player %s: %v", player.Name, err)
+	}
+	return true
+}
+
+// battleTurn has the player's first Pokémon attack the wild one and reports whether the encounter is over
+func battleTurn(player *Player, e *Encounter) bool {
+	mutex.Lock()
+	if len(player.Pokemons) == 0 {
+		mutex.Unlock()
+		player.Conn.Write([]byte("You have no Pokémon to battle with.\n"))
+		return false
+	}
+	lead := player.Pokemons[0]
+	_, ok := world.Get(e.Pokemon.ID)
+	mutex.Unlock()
+	if !ok {
+		player.Conn.Write([]byte(fmt.Sprintf("%s is gone.\n", e.Pokemon.Name)))
+		return true
+	}
+
+	damage := attackDamage(lead, &e.Pokemon.Pokemon)
+	e.HP = max(0, e.HP-damage)
+	player.Conn.Write([]byte(fmt.Sprintf("Your %s attacks! %s loses %d HP.\n", lead.Name, e.Pokemon.Name, damage)))
+	if e.HP > 0 {
+		return flee(player, e)
+	}
+	player.Conn.Write([]byte(fmt.Sprintf("%s fainted and can't be caught any more.\n", e.Pokemon.Name)))
+	leave(e.Pokemon, "fainted")
+	return true
+}
+
+// flee gives the wild Pokémon its flee_chance of running away after a failed throw or an attack,
+// and reports whether it did
+func flee(player *Player, e *Encounter) bool {
+	if rand.Float64() >= config.FleeChance {
+		return false
+	}
+	player.Conn.Write([]byte(fmt.Sprintf("%s fled!\n", e.Pokemon.Name)))
+	leave(e.Pokemon, "fled")
+	return true
+}
+
+// leave removes a wild Pokémon that fled or fainted before its disappear time
+func leave(pokemon WildPokemon, reason string) {
+	mutex.Lock()
+	_, removed := world.Remove(pokemon.ID)
+	despawns.Cancel(pokemon.ID)
+	mutex.Unlock()
+	if removed {
+		fmt.Printf("Pokémon %s at (%d, %d) %s\n", pokemon.Name, pokemon.X, pokemon.Y, reason)
+		events.Publish(WorldEvent{Kind: Despawned, Pokemon: pokemon})
+	}
+}
+
+// bag describes the balls a player holds
+func bag(player *Player) string {
+	mutex.Lock()
+	defer mutex.Unlock()
+	var held []string
+	for _, ball := range Balls {
+		if n := player.Balls[ball.Name]; n > 0 {
+			held = append(held, fmt.Sprintf("%d %s", n, ball.Label))
+		}
+	}
+	if len(held) == 0 {
+		return "Bag: no balls left."
+	}
+	return "Bag: " + strings.Join(held, ", ") + "."
+}
+
+// refillBalls adds ball_refill to the bag of every connected player each ball_refill_interval,
+// so players who threw all their balls can keep catching
+func refillBalls() {
+	ticker := time.NewTicker(config.BallRefillInterval.Duration)
+	defer ticker.Stop()
+	for range ticker.C {
+		refilled := make(map[*Player]string)
+		mutex.Lock()
+		for _, player := range playerList {
+			if added := refill(player); added != "" {
+				refilled[player] = added
+			}
+		}
+		mutex.Unlock()
+
+		for player, added := range refilled {
+			player.Conn.Write([]byte(fmt.Sprintf("Your bag was refilled with %s.\n", added)))
+		}
+	}
+}
+
+// refill adds ball_refill to a player's bag without going over starting_balls, and describes the
+// balls added. The caller must hold mutex.
+func refill(player *Player) string {
+	var added []string
+	for _, ball := range Balls {
+		n := min(config.BallRefill[ball.Name], config.StartingBalls[ball.Name]-player.Balls[ball.Name])
+		if n > 0 {
+			player.Balls[ball.Name] += n
+			added = append(added, fmt.Sprintf("%d %s", n, ball.Label))
+		}
+	}
+	return strings.Join(added, ", ")
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"testing"
+
+	"main/pokedex"
+)
+
+func TestFallbackCatchRate(t *testing.T) {
+	tests := []struct {
+		exp  int
+		want int
+	}{
+		{0, 255},
+		{39, 255},
+		{50, 255},
+		{100, 90},
+		{187, 35},
+		{340, 14},
+		{2000, 3},
+	}
+	for _, test := range tests {
+		if got := fallbackCatchRate(&pokedex.Pokemon{Exp: test.exp}); got != test.want {
+			t.Errorf("fallbackCatchRate with base EXP %d = %d, want %d", test.exp, got, test.want)
+		}
+	}
+}
+
+func TestCatchChance(t *testing.T) {
+	dex := testDex(t)
+	wild := func(name string, catchRate int) WildPokemon {
+		species, ok := dex.ByName(name)
+		if !ok {
+			t.Fatalf("%s is missing from the test dex", name)
+		}
+		pokemon := *species
+		pokemon.CatchRate = catchRate
+		return WildPokemon{Pokemon: pokemon}
+	}
+	poke, _ := findBall("poke")
+	ultra, _ := findBall("ultra")
+	master, _ := findBall("master")
+
+	caterpie, mewtwo := newEncounter(wild("Caterpie", 0)), newEncounter(wild("Mewtwo", 0))
+	if caterpie.CatchChance(poke) <= mewtwo.CatchChance(poke) {
+		t.Errorf("Caterpie catch chance %v is not above Mewtwo's %v without catch rates in the dex",
+			caterpie.CatchChance(poke), mewtwo.CatchChance(poke))
+	}
+	if mewtwo.CatchChance(master) != 1 {
+		t.Errorf("Master Ball catch chance %v, want 1", mewtwo.CatchChance(master))
+	}
+	if mewtwo.CatchChance(ultra) != 2*mewtwo.CatchChance(poke) {
+		t.Errorf("Ultra Ball catch chance %v is not twice the Poké Ball's %v", mewtwo.CatchChance(ultra), mewtwo.CatchChance(poke))
+	}
+
+	// A catch rate from the dex wins over the fallback, and a lower HP makes the catch easier
+	lapras := newEncounter(wild("Lapras", 45))
+	full := lapras.CatchChance(poke)
+	if want := 45.0 / 3 / 255; full < want-1e-9 || full > want+1e-9 {
+		t.Errorf("Lapras catch chance at full HP %v, want %v", full, want)
+	}
+	lapras.HP = 1
+	if lapras.CatchChance(poke) <= full {
+		t.Errorf("Lapras catch chance at 1 HP %v is not above %v at full HP", lapras.CatchChance(poke), full)
+	}
+}
+
+func TestAttackDamage(t *testing.T) {
+	weak := &pokedex.Pokemon{Stats: pokedex.Stats{HP: 50, Attack: 10, Defense: 10}}
+	strong := &pokedex.Pokemon{Stats: pokedex.Stats{HP: 50, Attack: 100, Defense: 100}}
+	// At level 50 the stats are 15 and 105, so the strong attacker hits the weak one for
+	// 22*40*105/15/50+2 = 125 before the random roll, where raw base stats would give 178
+	for i := 0; i < 100; i++ {
+		if damage := attackDamage(strong, weak); damage < 125*85/100 || damage > 125 {
+			t.Fatalf("attackDamage = %d, want 106 to 125", damage)
+		}
+	}
+	// The other way round it is 22*40*15/105/50+2 = 4 before the roll
+	if damage := attackDamage(weak, strong); damage < 3 || damage > 4 {
+		t.Errorf("attackDamage of the weak attacker = %d, want 3 or 4", damage)
+	}
+}
+
+func TestRefill(t *testing.T) {
+	defer func(saved Config) { config = saved }(config)
+	config = testConfig()
+	config.StartingBalls = map[string]int{"poke": 20, "great": 5, "ultra": 2}
+	config.BallRefill = map[string]int{"poke": 5, "great": 1, "ultra": 1}
+
+	tests := []struct {
+		name  string
+		balls map[string]int
+		want  map[string]int
+		added string
+	}{
+		{"empty bag", map[string]int{}, map[string]int{"poke": 5, "great": 1, "ultra": 1}, "5 Poké Ball, 1 Great Ball, 1 Ultra Ball"},
+		{"up to the starting balls", map[string]int{"poke": 18, "great": 5, "ultra": 1}, map[string]int{"poke": 20, "great": 5, "ultra": 2}, "2 Poké Ball, 1 Ultra Ball"},
+		{"full bag", map[string]int{"poke": 20, "great": 5, "ultra": 2, "master": 1}, map[string]int{"poke": 20, "great": 5, "ultra": 2, "master": 1}, ""},
+		{"more than the starting balls", map[string]int{"poke": 30, "great": 4, "ultra": 2}, map[string]int{"poke": 30, "great": 5, "ultra": 2}, "1 Great Ball"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			player := &Player{Balls: test.balls}
+			if added := refill(player); added != test.added {
+				t.Errorf("refill added %q, want %q", added, test.added)
+			}
+			for _, ball := range Balls {
+				if player.Balls[ball.Name] != test.want[ball.Name] {
+					t.Errorf("%s balls after the refill = %d, want %d", ball.Name, player.Balls[ball.Name], test.want[ball.Name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+// ConfigFile is the world configuration read from the data directory when -config is not given
+const ConfigFile = "pokecat.json"
+
+// Config holds the world and spawn parameters of PokeCat
+type Config struct {
+	Port               int      `json:"port"`                  // TCP port the server listens on
+	GridSize           int      `json:"grid_size"`             // Width and height of the world
+	MaxPokemonPerBatch int      `json:"max_pokemon_per_batch"` // Most Pokémon spawned at once, each batch has 1 to this many
+	PokemonDisappear   Duration `json:"pokemon_disappear"`     // Time after which a Pokémon disappears if not caught
+	MaxPokemonCapacity int      `json:"max_pokemon_capacity"`  // Maximum number of Pokémon a player can hold
+	MaxWildPokemon     int      `json:"max_wild_pokemon"`      // Most wild Pokémon on the grid at once
+	SpawnIntervalMin   Duration `json:"spawn_interval_min"`    // Shortest wait between two spawns
+	SpawnIntervalMax   Duration `json:"spawn_interval_max"`    // Longest wait between two spawns
+
+	Seed           int64              `json:"seed"`            // Seed of the spawner, random when 0
+	RarityExponent float64            `json:"rarity_exponent"` // How much rarer a higher base EXP makes a species
+	SpawnWeights   map[string]float64 `json:"spawn_weights"`   // Explicit weights by species name, 0 never spawns
+	Zones          []ZoneConfig       `json:"zones"`           // Regions with their own spawn tables
+
+	FleeChance         float64        `json:"flee_chance"`          // Chance of a wild Pokémon fleeing after a failed throw or an attack
+	StartingBalls      map[string]int `json:"starting_balls"`       // Balls of each kind new players start with
+	BallRefill         map[string]int `json:"ball_refill"`          // Balls of each kind added to connected players' bags, up to starting_balls
+	BallRefillInterval Duration       `json:"ball_refill_interval"` // Time between two refills, 0 turns them off
+}
+
+// Duration is a time.Duration written as a string like "5m" in the config file
+type Duration struct {
+	time.Duration
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("duration must be a string like \"5m\": %v", err)
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	d.Duration = duration
+	return nil
+}
+
+// DefaultConfig is used for every setting missing from the config file and the command line
+var DefaultConfig = Config{
+	Port:               8080,
+	GridSize:           10,
+	MaxPokemonPerBatch: 10,
+	PokemonDisappear:   Duration{300 * time.Second},
+	MaxPokemonCapacity: 200,
+	MaxWildPokemon:     50,
+	SpawnIntervalMin:   Duration{time.Second},
+	SpawnIntervalMax:   Duration{3 * time.Second},
+	RarityExponent:     2,
+	FleeChance:         0.1,
+	StartingBalls:      map[string]int{"poke": 20, "great": 5, "ultra": 2},
+	BallRefill:         map[string]int{"poke": 5, "great": 1},
+	BallRefillInterval: Duration{time.Minute},
+}
+
+// config is the configuration the server runs with
+var config = DefaultConfig
+
+// Command line overrides for the config file
+var (
+	configFile = flag.String("config", "", "JSON file with the world settings (default <data>/"+ConfigFile+" if it exists)")
+	port       = flag.Int("port", DefaultConfig.Port, "TCP port to listen on")
+	gridSize   = flag.Int("grid-size", DefaultConfig.GridSize, "width and height of the world")
+	batchSize  = flag.Int("batch", DefaultConfig.MaxPokemonPerBatch, "most Pokémon spawned at once, each batch has 1 to this many")
+	disappear  = flag.Duration("disappear", DefaultConfig.PokemonDisappear.Duration, "time after which an uncaught Pokémon disappears")
+	capacity   = flag.Int("capacity", DefaultConfig.MaxPokemonCapacity, "maximum number of Pokémon a player can hold")
+	maxWild    = flag.Int("max-wild", DefaultConfig.MaxWildPokemon, "most wild Pokémon on the grid at once")
+	fleeChance = flag.Float64("flee", DefaultConfig.FleeChance, "chance of a wild Pokémon fleeing after a failed throw or an attack")
+	spawnMin   = flag.Duration("spawn-min", DefaultConfig.SpawnIntervalMin.Duration, "shortest wait between two spawns")
+	spawnMax   = flag.Duration("spawn-max", DefaultConfig.SpawnIntervalMax.Duration, "longest wait between two spawns")
+	seed       = flag.Int64("seed", 0, "seed of the spawner, to reproduce the same spawns (default random)")
+	rarity     = flag.Float64("rarity", DefaultConfig.RarityExponent, "how much rarer a higher base EXP makes a species")
+	ballRefill = flag.Duration("ball-refill", DefaultConfig.BallRefillInterval.Duration, "time between two ball refills, 0 turns them off")
+)
+
+// loadConfig reads the config file, applies the flags set on the command line and validates the result.
+// The default file may be missing, but a file named with -config must exist.
+func loadConfig(defaultFile string) (Config, error) {
+	cfg := DefaultConfig
+	filename := *configFile
+	if filename == "" {
+		filename = defaultFile
+	}
+	file, err := os.ReadFile(filename)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && *configFile == "":
+	case err != nil:
+		return cfg, fmt.Errorf("failed to load config: %v", err)
+	default:
+		// Decoding into the default maps would add the file's balls to the default ones instead of replacing them
+		cfg.StartingBalls, cfg.BallRefill = nil, nil
+		decoder := json.NewDecoder(bytes.NewReader(file))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&cfg); err != nil {
+			return cfg, fmt.Errorf("failed to parse config %s: %v", filename, err)
+		}
+		if cfg.StartingBalls == nil {
+			cfg.StartingBalls = DefaultConfig.StartingBalls
+		}
+		if cfg.BallRefill == nil {
+			cfg.BallRefill = DefaultConfig.BallRefill
+		}
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "port":
+			cfg.Port = *port
+		case "grid-size":
+			cfg.GridSize = *gridSize
+		case "batch":
+			cfg.MaxPokemonPerBatch = *batchSize
+		case "disappear":
+			cfg.PokemonDisappear.Duration = *disappear
+		case "capacity":
+			cfg.MaxPokemonCapacity = *capacity
+		case "max-wild":
+			cfg.MaxWildPokemon = *maxWild
+		case "flee":
+			cfg.FleeChance = *fleeChance
+		case "spawn-min":
+			cfg.SpawnIntervalMin.Duration = *spawnMin
+		case "spawn-max":
+			cfg.SpawnIntervalMax.Duration = *spawnMax
+		case "seed":
+			cfg.Seed = *seed
+		case "rarity":
+			cfg.RarityExponent = *rarity
+		case "ball-refill":
+			cfg.BallRefillInterval.Duration = *ballRefill
+		}
+	})
+	return cfg, cfg.Validate()
+}
+
+// Validate reports every setting that is out of range
+func (c Config) Validate() error {
+	var problems []string
+	if c.Port < 1 || c.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("port %d must be between 1 and 65535", c.Port))
+	}
+	if c.GridSize < 1 {
+		problems = append(problems, fmt.Sprintf("grid_size %d must be at least 1", c.GridSize))
+	}
+	if c.MaxPokemonPerBatch < 1 {
+		problems = append(problems, fmt.Sprintf("max_pokemon_per_batch %d must be at least 1", c.MaxPokemonPerBatch))
+	}
+	if c.PokemonDisappear.Duration <= 0 {
+		problems = append(problems, fmt.Sprintf("pokemon_disappear %v must be positive", c.PokemonDisappear))
+	}
+	if c.MaxPokemonCapacity < 1 {
+		problems = append(problems, fmt.Sprintf("max_pokemon_capacity %d must be at least 1", c.MaxPokemonCapacity))
+	}
+	if c.MaxWildPokemon < 1 {
+		problems = append(problems, fmt.Sprintf("max_wild_pokemon %d must be at least 1", c.MaxWildPokemon))
+	}
+	if c.FleeChance < 0 || c.FleeChance > 1 {
+		problems = append(problems, fmt.Sprintf("flee_chance %v must be between 0 and 1", c.FleeChance))
+	}
+	for name, count := range c.StartingBalls {
+		if _, ok := findBall(name); !ok {
+			problems = append(problems, fmt.Sprintf("starting_balls names unknown ball %s", name))
+		}
+		if count < 0 {
+			problems = append(problems, fmt.Sprintf("starting_balls %s %d must not be negative", name, count))
+		}
+	}
+	for name, count := range c.BallRefill {
+		if _, ok := findBall(name); !ok {
+			problems = append(problems, fmt.Sprintf("ball_refill names unknown ball %s", name))
+		}
+		if count < 0 {
+			problems = append(problems, fmt.Sprintf("ball_refill %s %d must not be negative", name, count))
+		}
+		if count > 0 && c.StartingBalls[name] == 0 {
+			problems = append(problems, fmt.Sprintf("ball_refill %s never refills, as starting_balls has none to refill up to", name))
+		}
+	}
+	if c.BallRefillInterval.Duration < 0 {
+		problems = append(problems, fmt.Sprintf("ball_refill_interval %v must not be negative", c.BallRefillInterval))
+	}
+	if c.SpawnIntervalMin.Duration <= 0 {
+		problems = append(problems, fmt.Sprintf("spawn_interval_min %v must be positive", c.SpawnIntervalMin))
+	}
+	if c.SpawnIntervalMax.Duration < c.SpawnIntervalMin.Duration {
+		problems = append(problems, fmt.Sprintf("spawn_interval_max %v must not be shorter than spawn_interval_min %v", c.SpawnIntervalMax, c.SpawnIntervalMin))
+	}
+	if c.RarityExponent < 0 {
+		problems = append(problems, fmt.Sprintf("rarity_exponent %v must not be negative", c.RarityExponent))
+	}
+	for name, weight := range c.SpawnWeights {
+		if weight < 0 {
+			problems = append(problems, fmt.Sprintf("spawn_weights %s %v must not be negative", name, weight))
+		}
+	}
+	for i, zone := range c.Zones {
+		if zone.Name == "" {
+			zone.Name = fmt.Sprintf("#%d", i+1)
+		}
+		if zone.Width < 1 || zone.Height < 1 {
+			problems = append(problems, fmt.Sprintf("zone %s width and height must be at least 1", zone.Name))
+		}
+		if zone.X < 0 || zone.Y < 0 || zone.X+zone.Width > c.GridSize || zone.Y+zone.Height > c.GridSize {
+			problems = append(problems, fmt.Sprintf("zone %s must lie inside the %dx%d grid", zone.Name, c.GridSize, c.GridSize))
+		}
+		if zone.MaxPokemon < 0 {
+			problems = append(problems, fmt.Sprintf("zone %s max_pokemon %d must not be negative", zone.Name, zone.MaxPokemon))
+		}
+		for name, weight := range zone.Weights {
+			if weight < 0 {
+				problems = append(problems, fmt.Sprintf("zone %s weight %s %v must not be negative", zone.Name, name, weight))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
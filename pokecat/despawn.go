@@ -0,0 +1,152 @@
+package main
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// SpawnID identifies one spawn, so a despawn never removes a later Pokémon on the same tile
+type SpawnID uint64
+
+// despawnEntry is a spawn waiting for its disappear time
+type despawnEntry struct {
+	pokemon WildPokemon
+	index   int // Position in the heap, kept up to date for removals
+}
+
+// despawnHeap orders entries by disappear time, soonest first
+type despawnHeap []*despawnEntry
+
+func (h despawnHeap) Len() int { return len(h) }
+func (h despawnHeap) Less(i, j int) bool {
+	return h[i].pokemon.DisappearTime.Before(h[j].pokemon.DisappearTime)
+}
+func (h despawnHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+func (h *despawnHeap) Push(x any) {
+	entry := x.(*despawnEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+func (h *despawnHeap) Pop() any {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// DespawnCounts are the totals of a despawn scheduler
+type DespawnCounts struct {
+	Live      int // Spawns waiting to disappear
+	Expired   int // Spawns that disappeared
+	Cancelled int // Spawns removed before their time, e.g. caught
+}
+
+// DespawnScheduler makes every spawn disappear at its DisappearTime with a single goroutine and timer
+type DespawnScheduler struct {
+	mutex   sync.Mutex
+	heap    despawnHeap
+	entries map[SpawnID]*despawnEntry
+	counts  DespawnCounts
+	wake    chan struct{} // Signalled when the soonest disappear time changes
+	expire  func(WildPokemon) bool
+}
+
+// NewDespawnScheduler returns a scheduler calling expire for each spawn that reaches its disappear time.
+// expire reports whether it removed the spawn, which is false when the spawn was caught just before.
+// Run must be started for spawns to expire.
+func NewDespawnScheduler(expire func(WildPokemon) bool) *DespawnScheduler {
+	return &DespawnScheduler{
+		entries: make(map[SpawnID]*despawnEntry),
+		wake:    make(chan struct{}, 1),
+		expire:  expire,
+	}
+}
+
+// Schedule adds a spawn to disappear at its DisappearTime
+func (s *DespawnScheduler) Schedule(pokemon WildPokemon) {
+	s.mutex.Lock()
+	entry := &despawnEntry{pokemon: pokemon}
+	heap.Push(&s.heap, entry)
+	s.entries[pokemon.ID] = entry
+	s.counts.Live++
+	soonest := s.heap[0] == entry
+	s.mutex.Unlock()
+
+	if soonest {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Cancel keeps a spawn from expiring and reports whether it was still scheduled
+func (s *DespawnScheduler) Cancel(id SpawnID) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry, ok := s.entries[id]
+	if !ok {
+		return false
+	}
+	heap.Remove(&s.heap, entry.index)
+	delete(s.entries, id)
+	s.counts.Live--
+	s.counts.Cancelled++
+	return true
+}
+
+// Counts returns the number of live, expired and cancelled spawns
+func (s *DespawnScheduler) Counts() DespawnCounts {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.counts
+}
+
+// Run expires spawns as their time comes, forever
+func (s *DespawnScheduler) Run() {
+	for {
+		s.mutex.Lock()
+		now := time.Now()
+		var due []WildPokemon
+		for len(s.heap) > 0 && !s.heap[0].pokemon.DisappearTime.After(now) {
+			entry := heap.Pop(&s.heap).(*despawnEntry)
+			delete(s.entries, entry.pokemon.ID)
+			s.counts.Live--
+			due = append(due, entry.pokemon)
+		}
+		wait := time.Duration(-1)
+		if len(s.heap) > 0 {
+			wait = s.heap[0].pokemon.DisappearTime.Sub(now)
+		}
+		s.mutex.Unlock()
+
+		for _, pokemon := range due {
+			// A spawn caught between leaving the heap and expire is counted with the cancelled ones
+			expired := s.expire(pokemon)
+			s.mutex.Lock()
+			if expired {
+				s.counts.Expired++
+			} else {
+				s.counts.Cancelled++
+			}
+			s.mutex.Unlock()
+		}
+
+		if wait < 0 {
+			<-s.wake
+			continue
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
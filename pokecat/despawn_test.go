@@ -0,0 +1,61 @@
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDespawnScheduler(t *testing.T) {
+	var lock sync.Mutex
+	var expired []SpawnID
+	done := make(chan struct{})
+	scheduler := NewDespawnScheduler(func(pokemon WildPokemon) bool {
+		lock.Lock()
+		defer lock.Unlock()
+		expired = append(expired, pokemon.ID)
+		if len(expired) == 3 {
+			close(done)
+		}
+		// Spawn 4 stands for a Pokémon caught after leaving the heap, before expire removed it
+		return pokemon.ID != 4
+	})
+	go scheduler.Run()
+
+	now := time.Now()
+	for i, delay := range []time.Duration{40, 10, 30, 20} {
+		scheduler.Schedule(WildPokemon{ID: SpawnID(i + 1), DisappearTime: now.Add(delay * time.Millisecond)})
+	}
+	if counts := scheduler.Counts(); counts.Live != 4 {
+		t.Fatalf("Counts() = %+v after scheduling 4 spawns", counts)
+	}
+	if !scheduler.Cancel(3) {
+		t.Errorf("Cancel(3) = false for a scheduled spawn")
+	}
+	if scheduler.Cancel(3) {
+		t.Errorf("Cancel(3) = true for a spawn already cancelled")
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("spawns did not expire in time")
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	want := []SpawnID{2, 4, 1}
+	for i := range want {
+		if expired[i] != want[i] {
+			t.Fatalf("spawns expired in order %v, want %v", expired, want)
+		}
+	}
+
+	// The counts of the last expiry are updated after expire returns
+	deadline := time.Now().Add(time.Second)
+	for scheduler.Counts() != (DespawnCounts{Expired: 2, Cancelled: 2}) && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if counts := scheduler.Counts(); counts != (DespawnCounts{Expired: 2, Cancelled: 2}) {
+		t.Errorf("Counts() = %+v, want 2 expired and 2 cancelled", counts)
+	}
+}
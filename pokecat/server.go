@@ -2,39 +2,26 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
+	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
+	"maps"
 	"math/rand"
 	"net"
+	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
-)
 
-// Configuration constants
-const (
-	GridSize           = 10 // Grid size of the world
-	MaxPokemonPerBatch = 10    // Max number of Pokémon generated each time
-	PokemonDisappear   = 300   // Time in seconds, after which a Pokémon disappears if not caught (60 seconds = 1 minute)
-	MaxPokemonCapacity = 200  // Maximum number of Pokémon a player can hold
+	"main/pokedex"
 )
 
-// Pokemon represents the structure of a Pokémon
-type Pokemon struct {
-	Name          string    `json:"name"`
-	Types         []string  `json:"types"`
-	Number        string    `json:"number"`
-	Stats         struct {
-		HP     int `json:"hp"`
-		Attack int `json:"attack"`
-		Defense int `json:"defense"`
-		Speed  int `json:"speed"`
-		SpAtk  int `json:"sp_atk"`
-		SpDef  int `json:"sp_def"`
-	} `json:"stats"`
-	Exp           string    `json:"exp"`
+// WildPokemon is a Pokémon spawned on the grid
+type WildPokemon struct {
+	pokedex.Pokemon
+	ID            SpawnID   // Unique to this spawn
 	X             int       // X coordinate on the grid
 	Y             int       // Y coordinate on the grid
 	SpawnTime     time.Time // Spawn time
@@ -46,46 +33,85 @@ type Player struct {
 	Name     string
 	X        int // X coordinate on the grid
 	Y        int // Y coordinate on the grid
-	Pokemons []*Pokemon
+	Pokemons []*pokedex.Pokemon
 	Conn     net.Conn
+	Done     chan struct{}  // Closed when the player disconnects
+	Radar    int            // Range of the spawn radar in tiles, 0 when off
+	Balls    map[string]int // Balls left of each kind
+	saving   sync.Mutex     // Held through each save so an older snapshot is never written last
 }
 
 var (
-	pokemons        []Pokemon
-	mutex           sync.Mutex         // Mutex for safe access to shared data
-	playerList      []*Player          // Slice to store connected players
-	pokemonMap      map[string]Pokemon // Map to store Pokémon based on their position
-	disappearChannel chan Pokemon      // Channel to notify about disappearing Pokémon
+	dex         *pokedex.Dex      // Species loaded from pokedex.json
+	spawner     *Spawner          // Picks the species and tile of each spawn
+	mutex       sync.Mutex        // Mutex for safe access to shared data
+	playerList  []*Player         // Slice to store connected players
+	world       *World            // Wild Pokémon on the grid
+	nextSpawnID SpawnID           // ID of the latest spawn
+	despawns    *DespawnScheduler // Removes uncaught Pokémon at their disappear time
+	events      *Broadcaster      // Spawn, despawn and catch events for the players' radars
 )
 
 func main() {
+	dataDir := pokedex.DataDirFlag()
+	sample := flag.Int("spawn-sample", 0, "print the species of this many spawns and exit, to check the spawn tables")
+	flag.Parse()
+	if err := pokedex.CheckDataDir(*dataDir, pokedex.DexFile); err != nil {
+		log.Fatalf("%v", err)
+	}
+
 	// Load Pokémon data from pokedex.json file
-	err := loadPokemonData("pokedex.json")
+	var err error
+	dex, err = pokedex.Load(filepath.Join(*dataDir, pokedex.DexFile))
 	if err != nil {
 		log.Fatalf("Failed to load Pokémon data: %v", err)
 	}
 
+	// Load the world settings
+	config, err = loadConfig(filepath.Join(*dataDir, ConfigFile))
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	spawner, err = newSpawner(dex, config)
+	if err != nil {
+		log.Fatalf("Invalid spawn tables: %v", err)
+	}
+	if *sample > 0 {
+		printSpawnSample(spawner, *sample)
+		return
+	}
+
+	// Load saved players so returning players get their Pokémon back
+	storeFile = filepath.Join(*dataDir, pokedex.PlayerStoreFile)
+	err = loadPlayerStore(storeFile)
+	if err != nil {
+		log.Fatalf("Failed to load player store: %v", err)
+	}
+
 	// Start the server
-	listener, err := net.Listen("tcp", ":8080")
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", config.Port))
 	if err != nil {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 	defer listener.Close()
 
-	fmt.Println("Server started. Waiting for players...")
+	fmt.Printf("Server started on port %d with a %dx%d world. Waiting for players...\n", config.Port, config.GridSize, config.GridSize)
 
-	// Initialize map to store Pokémon based on their position
-	pokemonMap = make(map[string]Pokemon)
+	// Initialize the world holding the wild Pokémon
+	world = NewWorld()
 
-	// Channel to handle Pokémon spawn and disappear notifications
-	pokemonChannel := make(chan Pokemon, MaxPokemonPerBatch)
-	disappearChannel = make(chan Pokemon)
+	// Broadcaster telling players about spawns, and the scheduler removing uncaught Pokémon
+	events = NewBroadcaster()
+	despawns = NewDespawnScheduler(despawn)
+	go despawns.Run()
 
 	// Start routine to generate Pokémon
-	go generatePokemon(pokemonChannel)
+	go generatePokemon()
 
-	// Start routine to handle Pokémon disappear notifications
-	go handleDisappear()
+	// Give players balls back over time
+	if config.BallRefillInterval.Duration > 0 {
+		go refillBalls()
+	}
 
 	// Accept incoming connections
 	for {
@@ -96,100 +122,82 @@ func main() {
 		}
 
 		// Handle each player connection in a separate goroutine
-		go handlePlayer(conn, pokemonChannel)
+		go handlePlayer(conn)
 	}
 }
 
-// loadPokemonData loads Pokémon data from a JSON file
-func loadPokemonData(filename string) error {
-	file, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("failed to load Pokémon data file: %v", err)
-	}
-	err = json.Unmarshal(file, &pokemons)
-	if err != nil {
-		return fmt.Errorf("failed to parse Pokémon data: %v", err)
-	}
-	return nil
-}
-
-// generatePokemon generates Pokémon continuously and sends them to a channel
-func generatePokemon(pokemonChannel chan<- Pokemon) {
+// generatePokemon generates Pokémon continuously and announces them to the players
+func generatePokemon() {
 	for {
-		mutex.Lock()
-		// Generate a new Pokemon
-		if len(pokemons) == 0 {
-			// Reload pokemons from file if there are no more pokemons left in the slice
-			if err := loadPokemonData("pokedex.json"); err != nil {
-				log.Printf("Failed to reload Pokémon data: %v", err)
-				mutex.Unlock()
-				continue
-			}
+		for i := spawner.BatchSize(); i > 0; i-- {
+			spawnPokemon()
 		}
 
-		index := rand.Intn(len(pokemons))
-		pokemon := pokemons[index]
-		key := fmt.Sprintf("%d,%d", rand.Intn(GridSize), rand.Intn(GridSize))
-		pokemon.X, pokemon.Y = parsePosition(key)
-		pokemon.SpawnTime = time.Now()
-		pokemon.DisappearTime = pokemon.SpawnTime.Add(PokemonDisappear * time.Second)
+		// Wait for a random duration between the spawn intervals before spawning the next batch
+		time.Sleep(spawner.Interval())
+	}
+}
 
-		// Remove the spawned Pokemon from the list or mark as used
-		pokemons = append(pokemons[:index], pokemons[index+1:]...)
-		pokemonMap[key] = pokemon
+// spawnPokemon puts a new Pokémon on the grid and announces it, unless the population cap is reached
+func spawnPokemon() {
+	mutex.Lock()
+	// Generate a new Pokemon from the spawn table of its tile
+	species, x, y := spawner.Next()
+	if world.Full(x, y) {
+		// Skip this spawn while the world or the zone is at its population cap
 		mutex.Unlock()
+		return
+	}
+	nextSpawnID++
+	pokemon := WildPokemon{Pokemon: *species, ID: nextSpawnID, X: x, Y: y}
+	pokemon.SpawnTime = time.Now()
+	pokemon.DisappearTime = pokemon.SpawnTime.Add(config.PokemonDisappear.Duration)
+	world.Add(pokemon)
+	despawns.Schedule(pokemon)
+	mutex.Unlock()
 
-		// Send the Pokemon through the channel
-		pokemonChannel <- pokemon
-
-		fmt.Printf("A wild Pokémon appeared: %s at (%d, %d)\n", pokemon.Name, pokemon.X, pokemon.Y)
-
-		// Schedule disappearance
-		go func(p Pokemon) {
-			time.Sleep(PokemonDisappear * time.Second)
-			disappearChannel <- p
-		}(pokemon)
+	// Tell the players, without waiting for slow ones
+	events.Publish(WorldEvent{Kind: Spawned, Pokemon: pokemon})
 
-		// Wait for a random duration between 1 to 3 seconds before spawning the next Pokemon
-		waitDuration := time.Duration(rand.Intn(3) + 1)
-		time.Sleep(waitDuration * time.Second)
-	}
+	fmt.Printf("A wild Pokémon appeared: %s at (%d, %d)\n", pokemon.Name, pokemon.X, pokemon.Y)
 }
 
-// handleDisappear handles disappearance notifications for Pokémon
-func handleDisappear() {
-	for {
-		pokemon := <-disappearChannel
-		key := fmt.Sprintf("%d,%d", pokemon.X, pokemon.Y)
+// despawn removes a Pokémon that reached its disappear time and reports whether it was still there,
+// it may have been caught in the meantime
+func despawn(pokemon WildPokemon) bool {
+	mutex.Lock()
+	_, exists := world.Remove(pokemon.ID)
+	mutex.Unlock()
+	if exists {
 		fmt.Printf("Pokémon %s at (%d, %d) disappeared\n", pokemon.Name, pokemon.X, pokemon.Y)
-		delete(pokemonMap, key)
+		events.Publish(WorldEvent{Kind: Despawned, Pokemon: pokemon})
 	}
+	return exists
 }
 
 // handlePlayer handles each player's connection
-func handlePlayer(conn net.Conn, pokemonChannel <-chan Pokemon) {
+func handlePlayer(conn net.Conn) {
 	defer conn.Close()
 
-	player := &Player{
-		Conn: conn,
-		X:    rand.Intn(GridSize),
-		Y:    rand.Intn(GridSize),
+	scanner := bufio.NewScanner(conn)
+	player, ok := loginPlayer(conn, scanner)
+	if !ok {
+		return
 	}
+	defer logoutPlayer(player)
 
-	mutex.Lock()
-	playerList = append(playerList, player)
-	mutex.Unlock()
+	radar := events.Subscribe()
+	defer events.Unsubscribe(radar)
+	go runRadar(player, radar)
 
-	fmt.Printf("Player connected at (%d, %d)\n", player.X, player.Y)
+	x, y := position(player)
+	player.Conn.Write([]byte(fmt.Sprintf("Welcome, %s! You are at position (%d, %d)\n", player.Name, x, y)))
 
-	player.Conn.Write([]byte(fmt.Sprintf("You are at position (%d, %d)\n", player.X, player.Y)))
-
-	scanner := bufio.NewScanner(conn)
 	for {
-		player.Conn.Write([]byte("Choose your step: [s][w][a][d] or 'check' to see your Pokémon or 'auto <duration>' to enable auto mode\n"))
+		player.Conn.Write([]byte("Choose your step: [s][w][a][d] or 'check' to see your Pokémon or 'auto <duration>' to enable auto mode, 'look' to see the Pokémon on your tile, 'bag' to count your balls, 'radar <tiles>|off' to watch for spawns or 'world' for spawn counts\n"))
 
 		if !scanner.Scan() {
-			fmt.Printf("Player disconnected\n")
+			fmt.Printf("Player %s disconnected\n", player.Name)
 			break
 		}
 		command := strings.TrimSpace(scanner.Text())
@@ -198,27 +206,15 @@ func handlePlayer(conn net.Conn, pokemonChannel <-chan Pokemon) {
 		if len(args) > 0 {
 			switch args[0] {
 			case "s":
-				if player.Y > 0 {
-					player.Y--
-				}
+				movePlayer(player, 0, -1)
 			case "w":
-				if player.Y < GridSize-1 {
-					player.Y++
-				}
+				movePlayer(player, 0, 1)
 			case "a":
-				if player.X > 0 {
-					player.X--
-				}
+				movePlayer(player, -1, 0)
 			case "d":
-				if player.X < GridSize-1 {
-					player.X++
-				}
+				movePlayer(player, 1, 0)
 			case "check":
-				player.Conn.Write([]byte("Your Pokémon:\n"))
-				for _, p := range player.Pokemons {
-					player.Conn.Write([]byte(fmt.Sprintf("- %s\n", p.Name)))
-				}
-				player.Conn.Write([]byte("End of Pokémon list\n"))
+				check(player)
 				continue
 			case "auto":
 				if len(args) > 1 {
@@ -230,79 +226,212 @@ func handlePlayer(conn net.Conn, pokemonChannel <-chan Pokemon) {
 					go autoCatch(player, duration)
 				}
 				continue
+			case "bag":
+				player.Conn.Write([]byte(bag(player) + "\n"))
+				continue
+			case "look":
+				look(player)
+				continue
+			case "world":
+				counts := despawns.Counts()
+				player.Conn.Write([]byte(fmt.Sprintf("Wild Pokémon: %d live of at most %d, %d disappeared, %d caught, fled or fainted\n", counts.Live, config.MaxWildPokemon, counts.Expired, counts.Cancelled)))
+				continue
+			case "radar":
+				setRadar(player, args[1:])
+				continue
 			default:
 				player.Conn.Write([]byte("Invalid command. Try again.\n"))
 				continue
 			}
 		}
 
-		// Check if there is a Pokémon at the player's new position
+		x, y := position(player)
+		player.Conn.Write([]byte(fmt.Sprintf("Updated position: (%d, %d)\n", x, y)))
+
+		// Meet the Pokémon at the player's new position
+		if !encounter(player, scanner) {
+			fmt.Printf("Player %s disconnected\n", player.Name)
+			break
+		}
+	}
+}
+
+// check lists the player's Pokémon. Auto mode catches from another goroutine, so the list is copied under mutex.
+func check(player *Player) {
+	mutex.Lock()
+	pokemons := slices.Clone(player.Pokemons)
+	mutex.Unlock()
+
+	player.Conn.Write([]byte("Your Pokémon:\n"))
+	for _, p := range pokemons {
+		player.Conn.Write([]byte(fmt.Sprintf("- %s\n", p.Name)))
+	}
+	player.Conn.Write([]byte("End of Pokémon list\n"))
+}
+
+// look lists the Pokémon on the player's tile
+func look(player *Player) {
+	mutex.Lock()
+	pokemons := world.At(player.X, player.Y)
+	x, y := player.X, player.Y
+	mutex.Unlock()
+
+	if len(pokemons) == 0 {
+		player.Conn.Write([]byte(fmt.Sprintf("There are no Pokémon at (%d, %d).\n", x, y)))
+		return
+	}
+	player.Conn.Write([]byte(fmt.Sprintf("Pokémon at (%d, %d):\n", x, y)))
+	for _, p := range pokemons {
+		left := time.Until(p.DisappearTime).Round(time.Second)
+		player.Conn.Write([]byte(fmt.Sprintf("- %s (#%d, %s), leaves in %v\n", p.Name, p.Number, strings.Join(p.Types, "/"), left)))
+	}
+}
+
+// setRadar turns the player's spawn radar on with a range in tiles, or off
+func setRadar(player *Player, args []string) {
+	if len(args) == 0 {
+		player.Conn.Write([]byte("Usage: 'radar <tiles>' to watch for spawns within that many steps, 'radar off' to stop\n"))
+		return
+	}
+	radius := 0
+	if args[0] != "off" {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			player.Conn.Write([]byte("Radar range must be a number of tiles of at least 1, or 'off'.\n"))
+			return
+		}
+		radius = n
+	}
+
+	mutex.Lock()
+	player.Radar = radius
+	mutex.Unlock()
+	if radius == 0 {
+		player.Conn.Write([]byte("Radar off.\n"))
+	} else {
+		player.Conn.Write([]byte(fmt.Sprintf("Radar on: watching spawns within %d tiles.\n", radius)))
+	}
+}
+
+// loginPlayer asks for a player name and restores the saved Pokémon and position for returning players
+func loginPlayer(conn net.Conn, scanner *bufio.Scanner) (*Player, bool) {
+	for {
+		conn.Write([]byte("Enter your name: "))
+		if !scanner.Scan() {
+			return nil, false
+		}
+		name := strings.TrimSpace(scanner.Text())
+		if name == "" {
+			conn.Write([]byte("Name cannot be empty.\n"))
+			continue
+		}
+
 		mutex.Lock()
-		key := fmt.Sprintf("%d,%d", player.X, player.Y)
-		if pokemon, exists := pokemonMap[key]; exists && time.Now().Before(pokemon.DisappearTime) {
-			// Player catches the Pokémon
-			player.Pokemons = append(player.Pokemons, &pokemon)
-			fmt.Printf("Player caught Pokémon: %s\n", pokemon.Name)
-			player.Conn.Write([]byte(fmt.Sprintf("You caught Pokémon: %s\n", pokemon.Name)))
-
-			// Remove Pokémon from the map and update disappear channel
-			delete(pokemonMap, key)
+		if findPlayer(name) != nil {
 			mutex.Unlock()
+			conn.Write([]byte(fmt.Sprintf("Player %s is already connected. Choose another name.\n", name)))
+			continue
+		}
 
-			go func(p Pokemon) {
-				time.Sleep(PokemonDisappear * time.Second)
-				disappearChannel <- p
-			}(pokemon)
-		} else {
-			mutex.Unlock()
+		player := &Player{
+			Name:  name,
+			Conn:  conn,
+			X:     rand.Intn(config.GridSize),
+			Y:     rand.Intn(config.GridSize),
+			Done:  make(chan struct{}),
+			Balls: maps.Clone(config.StartingBalls),
 		}
-		player.Conn.Write([]byte(fmt.Sprintf("Updated position: (%d, %d)\n", player.X, player.Y)))
+		if save, ok := lookupPlayer(name); ok {
+			player.X, player.Y = save.X, save.Y
+			for i := range save.Pokemons {
+				player.Pokemons = append(player.Pokemons, &save.Pokemons[i])
+			}
+			if save.Balls != nil {
+				player.Balls = maps.Clone(save.Balls)
+			}
+		}
+		if player.Balls == nil {
+			player.Balls = make(map[string]int)
+		}
+		playerList = append(playerList, player)
+		x, y, count := player.X, player.Y, len(player.Pokemons)
+		mutex.Unlock()
+
+		fmt.Printf("Player %s connected at (%d, %d) with %d Pokémon\n", player.Name, x, y, count)
+		return player, true
 	}
 }
 
+// logoutPlayer saves a player's progress and removes them from the connected players
+func logoutPlayer(player *Player) {
+	close(player.Done)
+	if err := savePlayer(player); err != nil {
+		log.Printf("Failed to save player %s: %v", player.Name, err)
+	}
+
+	mutex.Lock()
+	for i, p := range playerList {
+		if p == player {
+			playerList = append(playerList[:i], playerList[i+1:]...)
+			break
+		}
+	}
+	mutex.Unlock()
+}
+
+// findPlayer returns the connected player with the given name, the caller must hold mutex
+func findPlayer(name string) *Player {
+	for _, p := range playerList {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// movePlayer moves a player one step, staying inside the grid, and returns the new position.
+// Auto mode and the radar use the position from other goroutines, so it is only read and changed under mutex.
+func movePlayer(player *Player, dx, dy int) (int, int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	player.X = max(0, min(config.GridSize-1, player.X+dx))
+	player.Y = max(0, min(config.GridSize-1, player.Y+dy))
+	return player.X, player.Y
+}
+
+// position returns a player's position
+func position(player *Player) (int, int) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	return player.X, player.Y
+}
+
 // autoCatch moves the player automatically for the specified duration and catches Pokémon when encountered
 func autoCatch(player *Player, duration time.Duration) {
 	stopTime := time.Now().Add(duration)
 	for time.Now().Before(stopTime) {
+		select {
+		case <-player.Done:
+			return
+		default:
+		}
+
+		var x, y int
 		direction := rand.Intn(4)
 		switch direction {
 		case 0:
-			if player.X < GridSize-1 {
-				player.X++
-			}
+			x, y = movePlayer(player, 1, 0)
 		case 1:
-			if player.X > 0 {
-				player.X--
-			}
+			x, y = movePlayer(player, -1, 0)
 		case 2:
-			if player.Y < GridSize-1 {
-				player.Y++
-			}
+			x, y = movePlayer(player, 0, 1)
 		case 3:
-			if player.Y > 0 {
-				player.Y--
-			}
+			x, y = movePlayer(player, 0, -1)
 		}
 
-		mutex.Lock()
-		key := fmt.Sprintf("%d,%d", player.X, player.Y)
-		if pokemon, exists := pokemonMap[key]; exists && time.Now().Before(pokemon.DisappearTime) {
-			player.Pokemons = append(player.Pokemons, &pokemon)
-			fmt.Printf("Player caught Pokémon: %s\n", pokemon.Name)
-			player.Conn.Write([]byte(fmt.Sprintf("You caught Pokémon: %s\n", pokemon.Name)))
-			delete(pokemonMap, key)
-		}
-		mutex.Unlock()
-
-		player.Conn.Write([]byte(fmt.Sprintf("Auto mode: Moved to (%d, %d)\n", player.X, player.Y)))
+		autoEncounter(player)
+		player.Conn.Write([]byte(fmt.Sprintf("Auto mode: Moved to (%d, %d)\n", x, y)))
 		time.Sleep(time.Second)
 	}
 	player.Conn.Write([]byte("Auto mode ended.\n"))
 }
-
-// parsePosition parses a position key into X and Y coordinates
-func parsePosition(key string) (int, int) {
-	var x, y int
-	fmt.Sscanf(key, "%d,%d", &x, &y)
-	return x, y
-}
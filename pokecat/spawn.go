@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"main/pokedex"
+)
+
+// ZoneConfig is a rectangle of the world with its own spawn table
+type ZoneConfig struct {
+	Name    string             `json:"name"`
+	X       int                `json:"x"`      // Left column of the zone
+	Y       int                `json:"y"`      // Bottom row of the zone
+	Width   int                `json:"width"`  // Number of columns
+	Height  int                `json:"height"` // Number of rows
+	Types   []string           `json:"types"`  // Only species of these types spawn here, every species if empty
+	Weights map[string]float64 `json:"weights"`
+	// Most Pokémon the zone holds at once, only max_wild_pokemon applies when 0
+	MaxPokemon int `json:"max_pokemon"`
+}
+
+// Contains reports whether a tile is inside the zone
+func (z ZoneConfig) Contains(x, y int) bool {
+	return x >= z.X && x < z.X+z.Width && y >= z.Y && y < z.Y+z.Height
+}
+
+// SpawnTable picks species at random in proportion to their weights
+type SpawnTable struct {
+	species    []*pokedex.Pokemon
+	cumulative []float64 // Running total of the weights, for a binary search
+}
+
+// newSpawnTable builds a table from species and their weights, leaving out species with no weight
+func newSpawnTable(species []*pokedex.Pokemon, weight func(*pokedex.Pokemon) float64) (*SpawnTable, error) {
+	table := &SpawnTable{}
+	total := 0.0
+	for _, pokemon := range species {
+		w := weight(pokemon)
+		if w <= 0 {
+			continue
+		}
+		total += w
+		table.species = append(table.species, pokemon)
+		table.cumulative = append(table.cumulative, total)
+	}
+	if len(table.species) == 0 {
+		return nil, fmt.Errorf("no species can spawn")
+	}
+	return table, nil
+}
+
+// Pick chooses a species
+func (t *SpawnTable) Pick(rng *rand.Rand) *pokedex.Pokemon {
+	target := rng.Float64() * t.cumulative[len(t.cumulative)-1]
+	i := sort.SearchFloat64s(t.cumulative, target)
+	if i == len(t.species) || t.cumulative[i] == target {
+		// SearchFloat64s finds the first total >= target, a total equal to target belongs to the next species
+		i = min(i+1, len(t.species)-1)
+	}
+	return t.species[i]
+}
+
+// Chance returns the probability of the table picking a species
+func (t *SpawnTable) Chance(pokemon *pokedex.Pokemon) float64 {
+	previous := 0.0
+	for i, species := range t.species {
+		if species == pokemon {
+			return (t.cumulative[i] - previous) / t.cumulative[len(t.cumulative)-1]
+		}
+		previous = t.cumulative[i]
+	}
+	return 0
+}
+
+// Spawner decides which species spawn where and when. With the same seed, dex and config it
+// produces the same spawns, so spawn distributions can be reproduced.
+type Spawner struct {
+	rng      *rand.Rand
+	config   Config
+	world    *SpawnTable
+	zones    []*SpawnTable // Tables of config.Zones, in the same order
+	gridSize int
+}
+
+// newSpawner builds the world and zone spawn tables from the dex and config
+func newSpawner(dex *pokedex.Dex, config Config) (*Spawner, error) {
+	seed := config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	all := make([]*pokedex.Pokemon, dex.Len())
+	for i := range all {
+		all[i] = dex.At(i)
+	}
+	for name := range config.SpawnWeights {
+		if _, ok := dex.ByName(name); !ok {
+			return nil, fmt.Errorf("spawn_weights names unknown species %s", name)
+		}
+	}
+
+	world, err := newSpawnTable(all, func(pokemon *pokedex.Pokemon) float64 {
+		return config.spawnWeight(pokemon, nil)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("world spawn table: %v", err)
+	}
+	spawner := &Spawner{rng: rand.New(rand.NewSource(seed)), config: config, world: world, gridSize: config.GridSize}
+
+	for _, zone := range config.Zones {
+		for name := range zone.Weights {
+			if _, ok := dex.ByName(name); !ok {
+				return nil, fmt.Errorf("zone %s names unknown species %s", zone.Name, name)
+			}
+		}
+		var species []*pokedex.Pokemon
+		for _, pokemon := range all {
+			if len(zone.Types) == 0 || hasAnyType(pokemon, zone.Types) {
+				species = append(species, pokemon)
+			}
+		}
+		zone := zone
+		table, err := newSpawnTable(species, func(pokemon *pokedex.Pokemon) float64 {
+			return config.spawnWeight(pokemon, &zone)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("zone %s spawn table: %v", zone.Name, err)
+		}
+		spawner.zones = append(spawner.zones, table)
+	}
+	return spawner, nil
+}
+
+// Next picks the tile and species of the next spawn
+func (s *Spawner) Next() (*pokedex.Pokemon, int, int) {
+	x, y := s.rng.Intn(s.gridSize), s.rng.Intn(s.gridSize)
+	return s.tableAt(x, y).Pick(s.rng), x, y
+}
+
+// BatchSize picks how many Pokémon spawn at once, between 1 and max_pokemon_per_batch
+func (s *Spawner) BatchSize() int {
+	return 1 + s.rng.Intn(s.config.MaxPokemonPerBatch)
+}
+
+// Interval picks a random wait before the next spawn
+func (s *Spawner) Interval() time.Duration {
+	spread := s.config.SpawnIntervalMax.Duration - s.config.SpawnIntervalMin.Duration
+	return s.config.SpawnIntervalMin.Duration + time.Duration(s.rng.Int63n(int64(spread)+1))
+}
+
+// tableAt returns the spawn table of the first zone containing a tile, or the world table
+func (s *Spawner) tableAt(x, y int) *SpawnTable {
+	for i, zone := range s.config.Zones {
+		if zone.Contains(x, y) {
+			return s.zones[i]
+		}
+	}
+	return s.world
+}
+
+// spawnWeight is a species' weight in the world table, or in a zone's table when zone is set.
+// Explicit weights win, otherwise the weight falls with base EXP so strong species are rare.
+func (c Config) spawnWeight(pokemon *pokedex.Pokemon, zone *ZoneConfig) float64 {
+	if zone != nil {
+		if w, ok := lookupWeight(zone.Weights, pokemon.Name); ok {
+			return w
+		}
+	}
+	if w, ok := lookupWeight(c.SpawnWeights, pokemon.Name); ok {
+		return w
+	}
+	return math.Pow(100/float64(pokemon.Exp), c.RarityExponent)
+}
+
+// lookupWeight finds a species in a weight map, ignoring the case of the name
+func lookupWeight(weights map[string]float64, name string) (float64, bool) {
+	for species, w := range weights {
+		if strings.EqualFold(species, name) {
+			return w, true
+		}
+	}
+	return 0, false
+}
+
+// hasAnyType reports whether a species has one of the types
+func hasAnyType(pokemon *pokedex.Pokemon, types []string) bool {
+	for _, t := range types {
+		if pokemon.HasType(strings.ToLower(t)) {
+			return true
+		}
+	}
+	return false
+}
+
+// printSpawnSample spawns n Pokémon without starting the server and prints how often each species
+// appeared next to its chance in the world table, to check a spawn configuration
+func printSpawnSample(spawner *Spawner, n int) {
+	counts := make(map[*pokedex.Pokemon]int)
+	for i := 0; i < n; i++ {
+		species, _, _ := spawner.Next()
+		counts[species]++
+	}
+	species := make([]*pokedex.Pokemon, 0, len(counts))
+	for pokemon := range counts {
+		species = append(species, pokemon)
+	}
+	sort.Slice(species, func(i, j int) bool { return counts[species[i]] > counts[species[j]] })
+
+	fmt.Printf("%d spawns, %d species:\n", n, len(species))
+	for _, pokemon := range species {
+		fmt.Printf("%-12s %6d  %5.2f%%  (world table %5.2f%%)\n", pokemon.Name, counts[pokemon],
+			100*float64(counts[pokemon])/float64(n), 100*spawner.world.Chance(pokemon))
+	}
+}
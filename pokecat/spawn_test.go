@@ -0,0 +1,190 @@
+package main
+
+import (
+	"testing"
+
+	"main/pokedex"
+)
+
+// testDex returns a small dex with common and rare species of a few types
+func testDex(t *testing.T) *pokedex.Dex {
+	t.Helper()
+	stats := pokedex.Stats{HP: 50, Attack: 50, Defense: 50, Speed: 50, SpAtk: 50, SpDef: 50}
+	dex, err := pokedex.New([]pokedex.Pokemon{
+		{Name: "Caterpie", Types: []string{"bug"}, Number: 10, Stats: stats, Exp: 39},
+		{Name: "Pidgey", Types: []string{"normal", "flying"}, Number: 16, Stats: stats, Exp: 50},
+		{Name: "Magikarp", Types: []string{"water"}, Number: 129, Stats: stats, Exp: 40},
+		{Name: "Lapras", Types: []string{"water", "ice"}, Number: 131, Stats: stats, Exp: 187},
+		{Name: "Mewtwo", Types: []string{"psychic"}, Number: 150, Stats: stats, Exp: 340},
+	})
+	if err != nil {
+		t.Fatalf("failed to build test dex: %v", err)
+	}
+	return dex
+}
+
+// testConfig returns the default config with a fixed seed
+func testConfig() Config {
+	cfg := DefaultConfig
+	cfg.Seed = 42
+	return cfg
+}
+
+// spawn is one result of Spawner.Next
+type spawn struct {
+	name string
+	x, y int
+}
+
+// spawnSequence returns the next n spawns of a spawner
+func spawnSequence(t *testing.T, spawner *Spawner, n int) []spawn {
+	t.Helper()
+	spawns := make([]spawn, n)
+	for i := range spawns {
+		species, x, y := spawner.Next()
+		spawns[i] = spawn{species.Name, x, y}
+	}
+	return spawns
+}
+
+func TestSpawnerSameSeedSameSequence(t *testing.T) {
+	dex := testDex(t)
+	first, err := newSpawner(dex, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := newSpawner(dex, testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := spawnSequence(t, first, 500), spawnSequence(t, second, 500)
+	for i := range a {
+		if a[i] != b[i] {
+			t.Fatalf("spawn %d differs with the same seed: %+v and %+v", i, a[i], b[i])
+		}
+	}
+	for i := 0; i < 50; i++ {
+		if first.Interval() != second.Interval() {
+			t.Fatalf("spawn interval %d differs with the same seed", i)
+		}
+	}
+
+	cfg := testConfig()
+	cfg.Seed = 43
+	other, err := newSpawner(dex, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := spawnSequence(t, other, 500)
+	same := true
+	for i := range a {
+		same = same && a[i] == c[i]
+	}
+	if same {
+		t.Errorf("seeds 42 and 43 gave the same 500 spawns")
+	}
+}
+
+func TestSpawnerWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights map[string]float64
+		never   []string // Species that must never spawn
+		always  []string // Species that must spawn at least once
+	}{
+		{"by base EXP", nil, nil, []string{"Caterpie", "Pidgey", "Magikarp", "Lapras", "Mewtwo"}},
+		{"zero weight", map[string]float64{"Mewtwo": 0, "caterpie": 0}, []string{"Mewtwo", "Caterpie"}, []string{"Pidgey", "Lapras"}},
+		{"only one", map[string]float64{"Caterpie": 0, "Pidgey": 0, "Magikarp": 0, "Mewtwo": 0}, []string{"Caterpie", "Pidgey", "Magikarp", "Mewtwo"}, []string{"Lapras"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.SpawnWeights = test.weights
+			spawner, err := newSpawner(testDex(t), cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			counts := make(map[string]int)
+			for _, s := range spawnSequence(t, spawner, 20000) {
+				counts[s.name]++
+			}
+			for _, name := range test.never {
+				if counts[name] > 0 {
+					t.Errorf("%s has weight 0 but spawned %d times", name, counts[name])
+				}
+			}
+			for _, name := range test.always {
+				if counts[name] == 0 {
+					t.Errorf("%s never spawned in 20000 spawns", name)
+				}
+			}
+		})
+	}
+}
+
+func TestSpawnerRarity(t *testing.T) {
+	spawner, err := newSpawner(testDex(t), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	counts := make(map[string]int)
+	for _, s := range spawnSequence(t, spawner, 20000) {
+		counts[s.name]++
+	}
+	// With the default exponent of 2, Caterpie's weight is (340/39)^2, about 76 times Mewtwo's
+	if counts["Caterpie"] < 20*counts["Mewtwo"] {
+		t.Errorf("Caterpie spawned %d times and Mewtwo %d times, want Mewtwo much rarer", counts["Caterpie"], counts["Mewtwo"])
+	}
+}
+
+func TestSpawnerZones(t *testing.T) {
+	cfg := testConfig()
+	cfg.Zones = []ZoneConfig{
+		{Name: "lake", X: 0, Y: 0, Width: 5, Height: 10, Types: []string{"Water"}, Weights: map[string]float64{"Lapras": 0}},
+	}
+	spawner, err := newSpawner(testDex(t), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inside, outside := 0, 0
+	for _, s := range spawnSequence(t, spawner, 5000) {
+		if !cfg.Zones[0].Contains(s.x, s.y) {
+			outside++
+			continue
+		}
+		inside++
+		if s.name != "Magikarp" {
+			t.Fatalf("%s spawned at (%d, %d) in the lake, which only has water types and no Lapras", s.name, s.x, s.y)
+		}
+	}
+	if inside == 0 || outside == 0 {
+		t.Errorf("%d spawns inside and %d outside the lake, want both", inside, outside)
+	}
+}
+
+func TestSpawnerErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(*Config)
+	}{
+		{"unknown species", func(c *Config) { c.SpawnWeights = map[string]float64{"Agumon": 1} }},
+		{"unknown zone species", func(c *Config) {
+			c.Zones = []ZoneConfig{{Name: "z", Width: 1, Height: 1, Weights: map[string]float64{"Agumon": 1}}}
+		}},
+		{"empty zone", func(c *Config) {
+			c.Zones = []ZoneConfig{{Name: "z", Width: 1, Height: 1, Types: []string{"fire"}}}
+		}},
+		{"nothing can spawn", func(c *Config) {
+			c.SpawnWeights = map[string]float64{"Caterpie": 0, "Pidgey": 0, "Magikarp": 0, "Lapras": 0, "Mewtwo": 0}
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := testConfig()
+			test.setup(&cfg)
+			if _, err := newSpawner(testDex(t), cfg); err == nil {
+				t.Errorf("newSpawner succeeded, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"main/pokedex"
+)
+
+var (
+	storeMutex  sync.Mutex                    // Mutex guarding playerStore and the store file
+	storeFile   string                        // JSON file holding every player's saved progress, in the data directory
+	playerStore map[string]pokedex.PlayerSave // Saved players keyed by name
+)
+
+// loadPlayerStore loads saved players from a JSON file, starting empty if the file does not exist yet
+func loadPlayerStore(filename string) error {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+
+	playerStore = make(map[string]pokedex.PlayerSave)
+	saves, err := pokedex.LoadPlayers(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, save := range saves {
+		playerStore[save.Name] = save
+	}
+	return nil
+}
+
+// lookupPlayer returns the saved state of a player, if any
+func lookupPlayer(name string) (pokedex.PlayerSave, bool) {
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	save, ok := playerStore[name]
+	return save, ok
+}
+
+// savePlayer snapshots a player's position and Pokémon and writes the whole store to disk.
+// Saves of the same player run one at a time, so concurrent saves are written in snapshot order.
+func savePlayer(player *Player) error {
+	player.saving.Lock()
+	defer player.saving.Unlock()
+
+	mutex.Lock()
+	save := pokedex.PlayerSave{
+		Name:     player.Name,
+		X:        player.X,
+		Y:        player.Y,
+		Pokemons: make([]pokedex.Pokemon, 0, len(player.Pokemons)),
+		Balls:    maps.Clone(player.Balls),
+	}
+	for _, p := range player.Pokemons {
+		save.Pokemons = append(save.Pokemons, *p)
+	}
+	mutex.Unlock()
+
+	storeMutex.Lock()
+	defer storeMutex.Unlock()
+	playerStore[save.Name] = save
+	return writePlayerStore(storeFile)
+}
+
+// writePlayerStore writes the store through a temporary file so a crash never leaves it half written
+func writePlayerStore(filename string) error {
+	saves := make([]pokedex.PlayerSave, 0, len(playerStore))
+	for _, save := range playerStore {
+		saves = append(saves, save)
+	}
+	sort.Slice(saves, func(i, j int) bool { return saves[i].Name < saves[j].Name })
+	data, err := json.MarshalIndent(saves, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode player store: %v", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write player store: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write player store: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write player store: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), filename); err != nil {
+		return fmt.Errorf("failed to write player store: %v", err)
+	}
+	return nil
+}
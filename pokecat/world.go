@@ -0,0 +1,96 @@
+package main
+
+import "fmt"
+
+// World holds the wild Pokémon on the grid, any number of them per tile. The caller must hold mutex.
+type World struct {
+	spawns map[SpawnID]WildPokemon
+	tiles  map[string][]SpawnID // Spawns on each "x,y" tile, oldest first
+}
+
+// NewWorld returns an empty world
+func NewWorld() *World {
+	return &World{
+		spawns: make(map[SpawnID]WildPokemon),
+		tiles:  make(map[string][]SpawnID),
+	}
+}
+
+// tileKey returns the key of a tile in the tiles map
+func tileKey(x, y int) string {
+	return fmt.Sprintf("%d,%d", x, y)
+}
+
+// Add puts a spawn on its tile
+func (w *World) Add(pokemon WildPokemon) {
+	w.spawns[pokemon.ID] = pokemon
+	key := tileKey(pokemon.X, pokemon.Y)
+	w.tiles[key] = append(w.tiles[key], pokemon.ID)
+}
+
+// Remove takes a spawn off the grid and reports whether it was there
+func (w *World) Remove(id SpawnID) (WildPokemon, bool) {
+	pokemon, ok := w.spawns[id]
+	if !ok {
+		return pokemon, false
+	}
+	delete(w.spawns, id)
+	key := tileKey(pokemon.X, pokemon.Y)
+	ids := w.tiles[key]
+	for i, other := range ids {
+		if other == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+	if len(ids) == 0 {
+		delete(w.tiles, key)
+	} else {
+		w.tiles[key] = ids
+	}
+	return pokemon, true
+}
+
+// Get returns a spawn if it is still on the grid
+func (w *World) Get(id SpawnID) (WildPokemon, bool) {
+	pokemon, ok := w.spawns[id]
+	return pokemon, ok
+}
+
+// At returns the spawns on a tile, oldest first
+func (w *World) At(x, y int) []WildPokemon {
+	var pokemons []WildPokemon
+	for _, id := range w.tiles[tileKey(x, y)] {
+		pokemons = append(pokemons, w.spawns[id])
+	}
+	return pokemons
+}
+
+// Len returns the number of spawns on the grid
+func (w *World) Len() int {
+	return len(w.spawns)
+}
+
+// CountIn returns the number of spawns inside a zone
+func (w *World) CountIn(zone ZoneConfig) int {
+	count := 0
+	for _, pokemon := range w.spawns {
+		if zone.Contains(pokemon.X, pokemon.Y) {
+			count++
+		}
+	}
+	return count
+}
+
+// Full reports whether a spawn on a tile would go over the world cap or the cap of the tile's zone
+func (w *World) Full(x, y int) bool {
+	if w.Len() >= config.MaxWildPokemon {
+		return true
+	}
+	for _, zone := range config.Zones {
+		if zone.Contains(x, y) {
+			return zone.MaxPokemon > 0 && w.CountIn(zone) >= zone.MaxPokemon
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package pokedex
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DataDirEnv is the environment variable naming the data directory shared by the crawler,
+// PokeCat and PokeBat
+const DataDirEnv = "POKEDEX_DATA_DIR"
+
+// DefaultDataDir is the data directory used when neither -data nor DataDirEnv is set,
+// relative to the repository root the programs are run from
+const DefaultDataDir = "data"
+
+// Files kept in the data directory
+const (
+	DexFile         = "pokedex.json"        // The species dex written by the crawler
+	MovesFile       = "moves.json"          // PokeBat's moves dataset
+	PlayerStoreFile = "player_pokemon.json" // PokeCat's saved players, also read by PokeBat
+	BattleLogFile   = "battle.log"          // PokeBat's verbose damage breakdown
+)
+
+// DataDirFlag registers the -data flag on the command line, defaulting to DataDirEnv or DefaultDataDir
+func DataDirFlag() *string {
+	return DataDirFlagSet(flag.CommandLine)
+}
+
+// DataDirFlagSet registers the -data flag on a flag set, for subcommands with their own flags
+func DataDirFlagSet(flags *flag.FlagSet) *string {
+	dir := os.Getenv(DataDirEnv)
+	if dir == "" {
+		dir = DefaultDataDir
+	}
+	return flags.String("data", dir, "directory holding pokedex.json, player saves and logs (or set "+DataDirEnv+")")
+}
+
+// CheckDataDir makes sure the data directory exists and holds the files a program needs at startup,
+// so a wrong working directory or setting fails with a clear message
+func CheckDataDir(dir string, files ...string) error {
+	info, err := os.Stat(dir)
+	if err != nil || !info.IsDir() {
+		abs, _ := filepath.Abs(dir)
+		return fmt.Errorf("data directory %s not found: run from the repository root, or point -data or %s at it", abs, DataDirEnv)
+	}
+	for _, file := range files {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			return fmt.Errorf("%s is missing from the data directory %s: %v", file, dir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package pokedex
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DexDiff lists what changed between two versions of the pokedex
+type DexDiff struct {
+	Added   []SpeciesRef    `json:"added"`
+	Removed []SpeciesRef    `json:"removed"`
+	Changed []SpeciesChange `json:"changed"`
+}
+
+// SpeciesRef names a species in a diff
+type SpeciesRef struct {
+	Number int    `json:"number"`
+	Name   string `json:"name"`
+}
+
+// SpeciesChange lists the fields that changed for a species present in both versions
+type SpeciesChange struct {
+	SpeciesRef
+	Fields []FieldChange `json:"fields"`
+}
+
+// FieldChange is one field with its old and new value. Nested fields are named like "stats.hp",
+// and a value is missing when the field is absent from that version.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old,omitempty"`
+	New   json.RawMessage `json:"new,omitempty"`
+}
+
+// Diff compares two versions of the pokedex, matching species by number
+func Diff(before []Pokemon, after []Pokemon) (DexDiff, error) {
+	diff := DexDiff{Added: []SpeciesRef{}, Removed: []SpeciesRef{}, Changed: []SpeciesChange{}}
+	oldByNumber := make(map[int]Pokemon)
+	for _, pokemon := range before {
+		oldByNumber[pokemon.Number] = pokemon
+	}
+	newByNumber := make(map[int]Pokemon)
+	for _, pokemon := range after {
+		newByNumber[pokemon.Number] = pokemon
+	}
+
+	for _, pokemon := range before {
+		if _, ok := newByNumber[pokemon.Number]; !ok {
+			diff.Removed = append(diff.Removed, SpeciesRef{Number: pokemon.Number, Name: pokemon.Name})
+		}
+	}
+	for _, pokemon := range after {
+		previous, ok := oldByNumber[pokemon.Number]
+		if !ok {
+			diff.Added = append(diff.Added, SpeciesRef{Number: pokemon.Number, Name: pokemon.Name})
+			continue
+		}
+		fields, err := diffFields(previous, pokemon)
+		if err != nil {
+			return diff, err
+		}
+		if len(fields) > 0 {
+			diff.Changed = append(diff.Changed, SpeciesChange{SpeciesRef{Number: pokemon.Number, Name: pokemon.Name}, fields})
+		}
+	}
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Number < diff.Removed[j].Number })
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Number < diff.Added[j].Number })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Number < diff.Changed[j].Number })
+	return diff, nil
+}
+
+// diffFields compares two entries field by field as they are written to pokedex.json
+func diffFields(before Pokemon, after Pokemon) ([]FieldChange, error) {
+	oldFields, err := flattenFields(before)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := flattenFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for name := range oldFields {
+		names[name] = true
+	}
+	for name := range newFields {
+		names[name] = true
+	}
+	var changes []FieldChange
+	for name := range names {
+		if !bytes.Equal(oldFields[name], newFields[name]) {
+			changes = append(changes, FieldChange{Field: name, Old: oldFields[name], New: newFields[name]})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes, nil
+}
+
+// flattenFields encodes an entry and returns the compact JSON of every field, descending into
+// nested objects so a single stat change is reported on its own. Lists are compared whole.
+func flattenFields(pokemon Pokemon) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(pokemon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s for the diff: %v", pokemon.Name, err)
+	}
+	fields := make(map[string]json.RawMessage)
+	var flatten func(prefix string, data json.RawMessage) error
+	flatten = func(prefix string, data json.RawMessage) error {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			fields[prefix] = data
+			return nil
+		}
+		for key, value := range object {
+			name := key
+			if prefix != "" {
+				name = prefix + "." + key
+			}
+			if err := flatten(name, value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fields, flatten("", data)
+}
+
+// Empty reports whether the two versions are the same
+func (d DexDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// String renders the diff for people to read
+func (d DexDiff) String() string {
+	if d.Empty() {
+		return "No changes to the pokedex.\n"
+	}
+	var report strings.Builder
+	report.WriteString(fmt.Sprintf("Pokedex changes: %d added, %d removed, %d changed\n", len(d.Added), len(d.Removed), len(d.Changed)))
+	for _, species := range d.Added {
+		report.WriteString(fmt.Sprintf("+ #%d %s\n", species.Number, species.Name))
+	}
+	for _, species := range d.Removed {
+		report.WriteString(fmt.Sprintf("- #%d %s\n", species.Number, species.Name))
+	}
+	for _, species := range d.Changed {
+		report.WriteString(fmt.Sprintf("~ #%d %s\n", species.Number, species.Name))
+		for _, field := range species.Fields {
+			report.WriteString(fmt.Sprintf("    %s: %s -> %s\n", field.Field, showValue(field.Old), showValue(field.New)))
+		}
+	}
+	return report.String()
+}
+
+// showValue renders a field value of the diff, or "(none)" when the field is absent
+func showValue(value json.RawMessage) string {
+	if len(value) == 0 {
+		return "(none)"
+	}
+	return string(value)
+}
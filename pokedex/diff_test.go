@@ -0,0 +1,95 @@
+package pokedex
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	bulbasaur, ivysaur, venusaur := species(1, "Bulbasaur", 64), species(2, "Ivysaur", 142), species(3, "Venusaur", 236)
+	fixedVenusaur := venusaur
+	fixedVenusaur.Exp = 263
+	fixedVenusaur.Stats.SpAtk = 100
+	fixedVenusaur.GrowthRate = "medium slow"
+	poisonIvysaur := ivysaur
+	poisonIvysaur.Types = []string{"grass", "poison"}
+
+	tests := []struct {
+		name   string
+		before []Pokemon
+		after  []Pokemon
+		want   DexDiff
+	}{
+		{
+			"no changes",
+			[]Pokemon{bulbasaur, ivysaur}, []Pokemon{ivysaur, bulbasaur},
+			DexDiff{Added: []SpeciesRef{}, Removed: []SpeciesRef{}, Changed: []SpeciesChange{}},
+		},
+		{
+			"added and removed in number order",
+			[]Pokemon{venusaur, bulbasaur}, []Pokemon{ivysaur, venusaur},
+			DexDiff{Added: []SpeciesRef{{2, "Ivysaur"}}, Removed: []SpeciesRef{{1, "Bulbasaur"}}, Changed: []SpeciesChange{}},
+		},
+		{
+			"no previous dex",
+			nil, []Pokemon{ivysaur, bulbasaur},
+			DexDiff{Added: []SpeciesRef{{1, "Bulbasaur"}, {2, "Ivysaur"}}, Removed: []SpeciesRef{}, Changed: []SpeciesChange{}},
+		},
+		{
+			"changed fields, nested and new",
+			[]Pokemon{bulbasaur, venusaur}, []Pokemon{bulbasaur, fixedVenusaur},
+			DexDiff{Added: []SpeciesRef{}, Removed: []SpeciesRef{}, Changed: []SpeciesChange{
+				{SpeciesRef{3, "Venusaur"}, []FieldChange{
+					{"exp", json.RawMessage("236"), json.RawMessage("263")},
+					{"growth_rate", nil, json.RawMessage(`"medium slow"`)},
+					{"stats.sp_atk", json.RawMessage("50"), json.RawMessage("100")},
+				}},
+			}},
+		},
+		{
+			"lists compared whole",
+			[]Pokemon{ivysaur}, []Pokemon{poisonIvysaur},
+			DexDiff{Added: []SpeciesRef{}, Removed: []SpeciesRef{}, Changed: []SpeciesChange{
+				{SpeciesRef{2, "Ivysaur"}, []FieldChange{{"types", json.RawMessage(`["normal"]`), json.RawMessage(`["grass","poison"]`)}}},
+			}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff, err := Diff(test.before, test.after)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(diff, test.want) {
+				t.Errorf("Diff = %+v, want %+v", diff, test.want)
+			}
+			if diff.Empty() != (test.name == "no changes") {
+				t.Errorf("Empty = %v for %s", diff.Empty(), test.name)
+			}
+		})
+	}
+}
+
+func TestDiffString(t *testing.T) {
+	if got := (DexDiff{}).String(); got != "No changes to the pokedex.\n" {
+		t.Errorf("String of an empty diff = %q", got)
+	}
+	diff := DexDiff{
+		Added:   []SpeciesRef{{4, "Charmander"}},
+		Removed: []SpeciesRef{{1, "Bulbasaur"}},
+		Changed: []SpeciesChange{{SpeciesRef{3, "Venusaur"}, []FieldChange{
+			{"exp", json.RawMessage("236"), json.RawMessage("263")},
+			{"growth_rate", nil, json.RawMessage(`"medium slow"`)},
+		}}},
+	}
+	want := "Pokedex changes: 1 added, 1 removed, 1 changed\n" +
+		"+ #4 Charmander\n" +
+		"- #1 Bulbasaur\n" +
+		"~ #3 Venusaur\n" +
+		"    exp: 236 -> 263\n" +
+		"    growth_rate: (none) -> \"medium slow\"\n"
+	if got := diff.String(); got != want {
+		t.Errorf("String = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,132 @@
+package pokedex
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ExportFormats lists the formats Export can write
+var ExportFormats = []string{"csv", "ndjson", "showdown"}
+
+// Export writes species entries in one of ExportFormats
+func Export(w io.Writer, format string, pokemons []Pokemon) error {
+	switch format {
+	case "csv":
+		return WriteCSV(w, pokemons)
+	case "ndjson":
+		return WriteNDJSON(w, pokemons)
+	case "showdown":
+		return WriteShowdown(w, pokemons)
+	}
+	return fmt.Errorf("unknown export format %q, expected one of %s", format, strings.Join(ExportFormats, ", "))
+}
+
+// csvHeader names the columns written by WriteCSV
+var csvHeader = []string{
+	"number", "name", "type1", "type2", "hp", "attack", "defense", "sp_atk", "sp_def", "speed", "total",
+	"exp", "abilities", "catch_rate", "female_percent", "growth_rate", "height_m", "weight_kg",
+}
+
+// WriteCSV writes one row per species for spreadsheets, leaving unknown values blank
+func WriteCSV(w io.Writer, pokemons []Pokemon) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	for _, pokemon := range pokemons {
+		types := append(append([]string(nil), pokemon.Types...), "", "")
+		stats := pokemon.Stats
+		var abilities []string
+		for _, ability := range pokemon.Abilities {
+			name := ability.Name
+			if ability.Hidden {
+				name += " (hidden)"
+			}
+			abilities = append(abilities, name)
+		}
+		female := ""
+		if pokemon.Gender != nil && !pokemon.Gender.Genderless {
+			female = strconv.FormatFloat(pokemon.Gender.Female, 'f', -1, 64)
+		}
+
+		row := []string{
+			strconv.Itoa(pokemon.Number), pokemon.Name, types[0], types[1],
+			strconv.Itoa(stats.HP), strconv.Itoa(stats.Attack), strconv.Itoa(stats.Defense),
+			strconv.Itoa(stats.SpAtk), strconv.Itoa(stats.SpDef), strconv.Itoa(stats.Speed),
+			strconv.Itoa(stats.HP + stats.Attack + stats.Defense + stats.SpAtk + stats.SpDef + stats.Speed),
+			optionalInt(pokemon.Exp), strings.Join(abilities, "; "), optionalInt(pokemon.CatchRate), female,
+			pokemon.GrowthRate, optionalFloat(pokemon.Height), optionalFloat(pokemon.Weight),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV: %v", err)
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write CSV: %v", err)
+	}
+	return nil
+}
+
+// WriteNDJSON writes one JSON object per line, in the same schema as pokedex.json entries
+func WriteNDJSON(w io.Writer, pokemons []Pokemon) error {
+	encoder := json.NewEncoder(w)
+	for _, pokemon := range pokemons {
+		if err := encoder.Encode(pokemon); err != nil {
+			return fmt.Errorf("failed to write NDJSON: %v", err)
+		}
+	}
+	return nil
+}
+
+// WriteShowdown writes a Pokémon Showdown import text, one set per species with its first regular
+// ability, separated by blank lines
+func WriteShowdown(w io.Writer, pokemons []Pokemon) error {
+	writer := bufio.NewWriter(w)
+	for i, pokemon := range pokemons {
+		if i > 0 {
+			writer.WriteString("\n")
+		}
+		writer.WriteString(ShowdownName(pokemon.Name) + "\n")
+		for _, ability := range pokemon.Abilities {
+			if !ability.Hidden {
+				writer.WriteString("Ability: " + ability.Name + "\n")
+				break
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return fmt.Errorf("failed to write Showdown text: %v", err)
+	}
+	return nil
+}
+
+// showdownGenders spells the gender signs of species names as Pokémon Showdown does, dropping the space
+// the dex may put before them
+var showdownGenders = strings.NewReplacer(" ♀", "-F", "♀", "-F", " ♂", "-M", "♂", "-M")
+
+// ShowdownName spells a species name the way Pokémon Showdown does, e.g. "Nidoran-F" for "Nidoran ♀"
+func ShowdownName(name string) string {
+	return showdownGenders.Replace(name)
+}
+
+// optionalInt formats a value that is zero when unknown
+func optionalInt(value int) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.Itoa(value)
+}
+
+// optionalFloat formats a value that is zero when unknown
+func optionalFloat(value float64) string {
+	if value == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
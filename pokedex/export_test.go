@@ -0,0 +1,87 @@
+package pokedex
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+)
+
+func TestShowdownName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Nidoran ♀", "Nidoran-F"},
+		{"Nidoran ♂", "Nidoran-M"},
+		{"Nidoran♀", "Nidoran-F"},
+		{"Nidoran♂", "Nidoran-M"},
+		{"Mr. Mime", "Mr. Mime"},
+		{"Farfetch'd", "Farfetch'd"},
+		{"Pikachu", "Pikachu"},
+	}
+	for _, test := range tests {
+		if got := ShowdownName(test.name); got != test.want {
+			t.Errorf("ShowdownName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestWriteShowdown(t *testing.T) {
+	nidoran := species(32, "Nidoran ♂", 55)
+	nidoran.Abilities = []Ability{{Name: "Hustle", Hidden: true}, {Name: "Poison Point"}, {Name: "Rivalry"}}
+	pokemons := []Pokemon{nidoran, species(132, "Ditto", 101)}
+
+	var out bytes.Buffer
+	if err := Export(&out, "showdown", pokemons); err != nil {
+		t.Fatal(err)
+	}
+	want := "Nidoran-M\nAbility: Poison Point\n\nDitto\n"
+	if out.String() != want {
+		t.Errorf("Showdown export = %q, want %q", out.String(), want)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	bulbasaur := species(1, "Bulbasaur", 64)
+	bulbasaur.Types = []string{"grass", "poison"}
+	bulbasaur.Stats = Stats{HP: 45, Attack: 49, Defense: 49, Speed: 45, SpAtk: 65, SpDef: 65}
+	bulbasaur.Abilities = []Ability{{Name: "Overgrow"}, {Name: "Chlorophyll", Hidden: true}}
+	bulbasaur.CatchRate = 45
+	bulbasaur.Gender = &GenderRatio{Female: 12.5}
+	bulbasaur.GrowthRate = "medium slow"
+	bulbasaur.Height, bulbasaur.Weight = 0.7, 6.9
+	ditto := species(132, "Ditto", 101)
+	ditto.Gender = &GenderRatio{Genderless: true}
+
+	var out bytes.Buffer
+	if err := Export(&out, "csv", []Pokemon{bulbasaur, ditto}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("export is not valid CSV: %v", err)
+	}
+	want := [][]string{
+		{"number", "name", "type1", "type2", "hp", "attack", "defense", "sp_atk", "sp_def", "speed", "total",
+			"exp", "abilities", "catch_rate", "female_percent", "growth_rate", "height_m", "weight_kg"},
+		{"1", "Bulbasaur", "grass", "poison", "45", "49", "49", "65", "65", "45", "318",
+			"64", "Overgrow; Chlorophyll (hidden)", "45", "12.5", "medium slow", "0.7", "6.9"},
+		{"132", "Ditto", "normal", "", "50", "50", "50", "50", "50", "50", "300",
+			"101", "", "", "", "", "", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("export has %d rows, want %d", len(rows), len(want))
+	}
+	for i := range want {
+		if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
+
+func TestExportUnknownFormat(t *testing.T) {
+	if err := Export(&bytes.Buffer{}, "xml", nil); err == nil {
+		t.Errorf("Export with format xml succeeded, want an error")
+	}
+}
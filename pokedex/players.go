@@ -0,0 +1,45 @@
+package pokedex
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// PlayerSave is a PokeCat player's progress as kept in PlayerStoreFile. PokeCat writes it,
+// PokeBat and the crawler's export read the captured Pokémon back.
+type PlayerSave struct {
+	Name     string         `json:"name"`
+	X        int            `json:"x"`
+	Y        int            `json:"y"`
+	Pokemons []Pokemon      `json:"pokemons"`        // Captured Pokémon, in the order they were caught
+	Balls    map[string]int `json:"balls,omitempty"` // Balls left of each kind, the starting balls when missing
+}
+
+// LoadPlayers reads every save in a player store. A missing file is reported with an error wrapping
+// fs.ErrNotExist, so callers can start with no players.
+func LoadPlayers(filename string) ([]PlayerSave, error) {
+	file, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load player store: %w", err)
+	}
+	var saves []PlayerSave
+	if err := json.Unmarshal(file, &saves); err != nil {
+		return nil, fmt.Errorf("failed to parse player store %s: %v", filename, err)
+	}
+	return saves, nil
+}
+
+// LoadPlayer returns the save of one player from a player store
+func LoadPlayer(filename string, name string) (PlayerSave, error) {
+	saves, err := LoadPlayers(filename)
+	if err != nil {
+		return PlayerSave{}, err
+	}
+	for _, save := range saves {
+		if save.Name == name {
+			return save, nil
+		}
+	}
+	return PlayerSave{}, fmt.Errorf("no PokeCat save for player %s", name)
+}
@@ -0,0 +1,162 @@
+// Package pokedex owns the Pokémon species schema shared by the crawler, PokeCat and PokeBat,
+// and loads pokedex.json into a Dex with lookups by number, name and type.
+package pokedex
+
+import (
+	"sort"
+	"strings"
+)
+
+// Pokemon is a species entry of the pokedex
+type Pokemon struct {
+	Name   string   `json:"name"`
+	Types  []string `json:"types"`
+	Number int      `json:"number"`
+	Stats  Stats    `json:"stats"`
+	Exp    int      `json:"exp"`
+
+	// Extended data, omitted when the crawler could not find it
+	Abilities  []Ability    `json:"abilities,omitempty"`
+	EVYield    *Stats       `json:"ev_yield,omitempty"`
+	CatchRate  int          `json:"catch_rate,omitempty"`
+	Gender     *GenderRatio `json:"gender,omitempty"`
+	GrowthRate string       `json:"growth_rate,omitempty"`
+	Height     float64      `json:"height_m,omitempty"`
+	Weight     float64      `json:"weight_kg,omitempty"`
+	Evolutions []Evolution  `json:"evolutions,omitempty"`
+}
+
+// Ability is an ability a species can have
+type Ability struct {
+	Name   string `json:"name"`
+	Hidden bool   `json:"hidden,omitempty"`
+}
+
+// GenderRatio is the chance of a wild Pokémon being female
+type GenderRatio struct {
+	Female     float64 `json:"female"` // Percentage of females
+	Genderless bool    `json:"genderless,omitempty"`
+}
+
+// Evolution is a species this one evolves into and how
+type Evolution struct {
+	Into      string `json:"into"`
+	Method    string `json:"method"`              // One of EvolutionMethods
+	Level     int    `json:"level,omitempty"`     // Minimum level for the level method
+	Condition string `json:"condition,omitempty"` // Item or other requirement, as written by the source
+}
+
+// Stats are the base stats of a species
+type Stats struct {
+	HP      int `json:"hp"`
+	Attack  int `json:"attack"`
+	Defense int `json:"defense"`
+	Speed   int `json:"speed"`
+	SpAtk   int `json:"sp_atk"`
+	SpDef   int `json:"sp_def"`
+}
+
+// AtLevel returns the stats of the species at a level, without IVs or EVs
+func (s Stats) AtLevel(level int) Stats {
+	stat := func(base int) int { return 2*base*level/100 + 5 }
+	return Stats{
+		HP:      2*s.HP*level/100 + level + 10,
+		Attack:  stat(s.Attack),
+		Defense: stat(s.Defense),
+		Speed:   stat(s.Speed),
+		SpAtk:   stat(s.SpAtk),
+		SpDef:   stat(s.SpDef),
+	}
+}
+
+// Dex is a loaded pokedex with indexes for fast lookups. It must not be modified after loading.
+type Dex struct {
+	pokemons []Pokemon
+	byNumber map[int]*Pokemon
+	byName   map[string]*Pokemon
+	byType   map[string][]*Pokemon
+}
+
+// New builds a Dex from species entries, rejecting them if they break the schema
+func New(pokemons []Pokemon) (*Dex, error) {
+	if problems := Validate(pokemons, nil); len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return index(pokemons), nil
+}
+
+// index builds the lookup tables of a Dex from valid species entries
+func index(pokemons []Pokemon) *Dex {
+	dex := &Dex{
+		pokemons: pokemons,
+		byNumber: make(map[int]*Pokemon),
+		byName:   make(map[string]*Pokemon),
+		byType:   make(map[string][]*Pokemon),
+	}
+	for i := range dex.pokemons {
+		pokemon := &dex.pokemons[i]
+		dex.byNumber[pokemon.Number] = pokemon
+		dex.byName[strings.ToLower(pokemon.Name)] = pokemon
+		for _, t := range pokemon.Types {
+			dex.byType[t] = append(dex.byType[t], pokemon)
+		}
+	}
+	return dex
+}
+
+// Len returns the number of species in the dex
+func (d *Dex) Len() int {
+	return len(d.pokemons)
+}
+
+// All returns a copy of every species in dex order
+func (d *Dex) All() []Pokemon {
+	return append([]Pokemon(nil), d.pokemons...)
+}
+
+// At returns the species at an index in dex order
+func (d *Dex) At(i int) *Pokemon {
+	return &d.pokemons[i]
+}
+
+// ByNumber returns the species with a national dex number
+func (d *Dex) ByNumber(number int) (*Pokemon, bool) {
+	pokemon, ok := d.byNumber[number]
+	return pokemon, ok
+}
+
+// ByName returns the species with a name, ignoring case
+func (d *Dex) ByName(name string) (*Pokemon, bool) {
+	pokemon, ok := d.byName[strings.ToLower(strings.TrimSpace(name))]
+	return pokemon, ok
+}
+
+// ByType returns every species of a type in dex order
+func (d *Dex) ByType(t string) []*Pokemon {
+	return d.byType[strings.ToLower(t)]
+}
+
+// HasType reports whether the species has a type
+func (p *Pokemon) HasType(t string) bool {
+	for _, own := range p.Types {
+		if own == t {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeRange replaces the species numbered first to last in a pokedex with freshly crawled ones and
+// keeps the species outside that range, returning the entries in dex order. A species of the range
+// missing from crawled is dropped.
+func MergeRange(previous []Pokemon, crawled []Pokemon, first int, last int) []Pokemon {
+	var merged []Pokemon
+	for _, pokemon := range previous {
+		if pokemon.Number < first || pokemon.Number > last {
+			merged = append(merged, pokemon)
+		}
+	}
+	merged = append(merged, crawled...)
+	sort.SliceStable(merged, func(i, j int) bool { return merged[i].Number < merged[j].Number })
+	return merged
+}
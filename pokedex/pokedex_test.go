@@ -0,0 +1,77 @@
+package pokedex
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// species returns a valid entry for tests
+func species(number int, name string, exp int) Pokemon {
+	return Pokemon{
+		Name:   name,
+		Types:  []string{"normal"},
+		Number: number,
+		Stats:  Stats{HP: 50, Attack: 50, Defense: 50, Speed: 50, SpAtk: 50, SpDef: 50},
+		Exp:    exp,
+	}
+}
+
+func TestMergeRange(t *testing.T) {
+	previous := []Pokemon{species(1, "Bulbasaur", 64), species(2, "Ivysaur", 142), species(3, "Venusaur", 236), species(4, "Charmander", 62), species(5, "Charmeleon", 142)}
+	tests := []struct {
+		name        string
+		previous    []Pokemon
+		crawled     []Pokemon
+		first, last int
+		want        []Pokemon
+	}{
+		{
+			"sub-range keeps the rest",
+			previous, []Pokemon{species(3, "Venusaur", 263), species(2, "Ivysaur", 142)}, 2, 3,
+			[]Pokemon{species(1, "Bulbasaur", 64), species(2, "Ivysaur", 142), species(3, "Venusaur", 263), species(4, "Charmander", 62), species(5, "Charmeleon", 142)},
+		},
+		{
+			"first species",
+			previous, []Pokemon{species(1, "Bulbasaur", 64)}, 1, 1,
+			previous,
+		},
+		{
+			"missing number of the range is dropped",
+			previous, []Pokemon{species(1, "Bulbasaur", 64), species(3, "Venusaur", 236)}, 1, 3,
+			[]Pokemon{species(1, "Bulbasaur", 64), species(3, "Venusaur", 236), species(4, "Charmander", 62), species(5, "Charmeleon", 142)},
+		},
+		{
+			"range past the dex adds species",
+			previous[:2], []Pokemon{species(2, "Ivysaur", 142), species(3, "Venusaur", 236)}, 2, 3,
+			previous[:3],
+		},
+		{
+			"no previous dex",
+			nil, []Pokemon{species(4, "Charmander", 62)}, 4, 4,
+			[]Pokemon{species(4, "Charmander", 62)},
+		},
+		{
+			"whole dex",
+			previous, previous[1:], 1, 640,
+			previous[1:],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := MergeRange(test.previous, test.crawled, test.first, test.last)
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("MergeRange(#%d-#%d) = %v, want %v", test.first, test.last, names(got), names(test.want))
+			}
+		})
+	}
+}
+
+// names lists the numbers and names of entries, for test failures
+func names(pokemons []Pokemon) []string {
+	var list []string
+	for _, pokemon := range pokemons {
+		list = append(list, fmt.Sprintf("#%d %s %d", pokemon.Number, pokemon.Name, pokemon.Exp))
+	}
+	return list
+}
@@ -0,0 +1,394 @@
+package pokedex
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SchemaVersion is the version of the pokedex.json layout written by Save.
+// Version 1 files are a bare array with the number and base EXP stored as strings.
+const SchemaVersion = 2
+
+// KnownTypes lists every type name a species may have
+var KnownTypes = []string{
+	"normal", "fire", "water", "electric", "grass", "ice", "fighting", "poison", "ground",
+	"flying", "psychic", "bug", "rock", "ghost", "dragon", "dark", "steel", "fairy",
+}
+
+// GrowthRates lists the experience curves a species may level up with
+var GrowthRates = []string{"erratic", "fast", "medium fast", "medium slow", "slow", "fluctuating"}
+
+// EvolutionMethods lists how a species may evolve
+var EvolutionMethods = []string{"level", "item", "other"}
+
+// File is the layout of pokedex.json
+type File struct {
+	Version  int       `json:"version"`
+	Pokemons []Pokemon `json:"pokemons"`
+}
+
+// legacyPokemon is a species entry of a version 1 file
+type legacyPokemon struct {
+	Name   string   `json:"name"`
+	Types  []string `json:"types"`
+	Number string   `json:"number"`
+	Stats  Stats    `json:"stats"`
+	Exp    string   `json:"exp"`
+}
+
+// entry is an undecoded species entry and the line it starts on
+type entry struct {
+	line int
+	data json.RawMessage
+}
+
+// Problem is a schema violation, with the line it was found on when it comes from a file
+type Problem struct {
+	Line    int
+	Message string
+}
+
+func (p Problem) String() string {
+	if p.Line == 0 {
+		return p.Message
+	}
+	return fmt.Sprintf("line %d: %s", p.Line, p.Message)
+}
+
+// ValidationError lists every problem found in a pokedex
+type ValidationError struct {
+	File     string
+	Problems []Problem
+}
+
+func (e *ValidationError) Error() string {
+	var message strings.Builder
+	name := e.File
+	if name == "" {
+		name = "pokedex"
+	}
+	message.WriteString(fmt.Sprintf("%s has %d problem(s):", name, len(e.Problems)))
+	for _, problem := range e.Problems {
+		message.WriteString("\n  " + problem.String())
+	}
+	return message.String()
+}
+
+// Load reads a pokedex file and indexes it for lookups
+func Load(filename string) (*Dex, error) {
+	pokemons, err := Read(filename)
+	if err != nil {
+		return nil, err
+	}
+	return index(pokemons), nil
+}
+
+// Read reads and validates a pokedex file of any schema version, reporting problems by line
+func Read(filename string) ([]Pokemon, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load pokedex file: %v", err)
+	}
+	pokemons, lines, problems := decode(data)
+	problems = append(problems, Validate(pokemons, lines)...)
+	if len(problems) > 0 {
+		return nil, &ValidationError{File: filename, Problems: problems}
+	}
+	return pokemons, nil
+}
+
+// Save writes species entries to a pokedex file in the current schema version
+func Save(filename string, pokemons []Pokemon) error {
+	data, err := json.MarshalIndent(File{Version: SchemaVersion, Pokemons: pokemons}, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to encode pokedex: %v", err)
+	}
+	if err := os.WriteFile(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write pokedex file: %v", err)
+	}
+	return nil
+}
+
+// Validate checks species entries against the schema. lines holds the line each entry starts on
+// and may be nil, in which case problems name the entry's position instead.
+func Validate(pokemons []Pokemon, lines []int) []Problem {
+	var problems []Problem
+	known := make(map[string]bool, len(KnownTypes))
+	for _, t := range KnownTypes {
+		known[t] = true
+	}
+	byNumber := make(map[int]int)
+	byName := make(map[string]int)
+
+	for i, pokemon := range pokemons {
+		report := func(format string, args ...interface{}) {
+			problem := Problem{Message: fmt.Sprintf(format, args...)}
+			if lines != nil {
+				problem.Line = lines[i]
+			} else {
+				problem.Message = fmt.Sprintf("entry %d: %s", i+1, problem.Message)
+			}
+			problems = append(problems, problem)
+		}
+		where := func(j int) string {
+			if lines != nil {
+				return fmt.Sprintf("line %d", lines[j])
+			}
+			return fmt.Sprintf("entry %d", j+1)
+		}
+
+		name := pokemon.Name
+		if name == "" {
+			report("species has no name")
+			name = fmt.Sprintf("#%d", pokemon.Number)
+		} else if j, exists := byName[strings.ToLower(name)]; exists {
+			report("%s is already listed at %s", name, where(j))
+		} else {
+			byName[strings.ToLower(name)] = i
+		}
+
+		if pokemon.Number <= 0 {
+			report("%s has invalid number %d", name, pokemon.Number)
+		} else if j, exists := byNumber[pokemon.Number]; exists {
+			report("%s has number %d, already used by %s at %s", name, pokemon.Number, pokemons[j].Name, where(j))
+		} else {
+			byNumber[pokemon.Number] = i
+		}
+
+		if len(pokemon.Types) == 0 {
+			report("%s has no types", name)
+		}
+		for _, t := range pokemon.Types {
+			if !known[t] {
+				report("%s has unknown type %q", name, t)
+			}
+		}
+
+		stats := []struct {
+			name  string
+			value int
+		}{
+			{"hp", pokemon.Stats.HP},
+			{"attack", pokemon.Stats.Attack},
+			{"defense", pokemon.Stats.Defense},
+			{"speed", pokemon.Stats.Speed},
+			{"sp_atk", pokemon.Stats.SpAtk},
+			{"sp_def", pokemon.Stats.SpDef},
+		}
+		for _, stat := range stats {
+			if stat.value <= 0 {
+				report("%s has %s stat %d", name, stat.name, stat.value)
+			}
+		}
+		if pokemon.Exp <= 0 {
+			report("%s has base exp %d", name, pokemon.Exp)
+		}
+		validateExtended(pokemon, name, report)
+	}
+	return problems
+}
+
+// validateExtended checks the optional species data that is present
+func validateExtended(pokemon Pokemon, name string, report func(format string, args ...interface{})) {
+	for _, ability := range pokemon.Abilities {
+		if ability.Name == "" {
+			report("%s has an ability without a name", name)
+		}
+	}
+	if ev := pokemon.EVYield; ev != nil {
+		total := 0
+		for _, value := range []int{ev.HP, ev.Attack, ev.Defense, ev.Speed, ev.SpAtk, ev.SpDef} {
+			if value < 0 {
+				report("%s has a negative EV yield", name)
+			}
+			total += value
+		}
+		if total < 1 || total > 3 {
+			report("%s yields %d EVs in total, expected 1 to 3", name, total)
+		}
+	}
+	if pokemon.CatchRate < 0 || pokemon.CatchRate > 255 {
+		report("%s has catch rate %d, expected 1 to 255", name, pokemon.CatchRate)
+	}
+	if gender := pokemon.Gender; gender != nil && (gender.Female < 0 || gender.Female > 100) {
+		report("%s has %g%% females", name, gender.Female)
+	}
+	if pokemon.GrowthRate != "" && !contains(GrowthRates, pokemon.GrowthRate) {
+		report("%s has unknown growth rate %q", name, pokemon.GrowthRate)
+	}
+	if pokemon.Height < 0 || pokemon.Weight < 0 {
+		report("%s has a negative height or weight", name)
+	}
+	for _, evolution := range pokemon.Evolutions {
+		switch {
+		case evolution.Into == "":
+			report("%s has an evolution without a species", name)
+		case !contains(EvolutionMethods, evolution.Method):
+			report("%s evolves into %s by unknown method %q", name, evolution.Into, evolution.Method)
+		case evolution.Method == "level" && (evolution.Level < 1 || evolution.Level > 100):
+			report("%s evolves into %s at level %d", name, evolution.Into, evolution.Level)
+		}
+	}
+}
+
+// contains reports whether a list holds a value
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// decode parses a pokedex file, upgrading version 1 entries, and returns the line each entry starts on
+func decode(data []byte) ([]Pokemon, []int, []Problem) {
+	version, entries, err := split(data)
+	if err != nil {
+		return nil, nil, []Problem{syntaxProblem(data, err)}
+	}
+	if version != 1 && version != SchemaVersion {
+		return nil, nil, []Problem{{Message: fmt.Sprintf("unsupported schema version %d, expected %d", version, SchemaVersion)}}
+	}
+
+	var problems []Problem
+	pokemons := make([]Pokemon, 0, len(entries))
+	lines := make([]int, 0, len(entries))
+	for _, e := range entries {
+		pokemon, err := decodeEntry(version, e.data)
+		if err != nil {
+			problems = append(problems, Problem{Line: e.line, Message: err.Error()})
+			continue
+		}
+		pokemons = append(pokemons, pokemon)
+		lines = append(lines, e.line)
+	}
+	return pokemons, lines, problems
+}
+
+// split finds the schema version and the raw species entries of a pokedex file
+func split(data []byte) (int, []entry, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	token, err := decoder.Token()
+	if err != nil {
+		return 0, nil, err
+	}
+	if token == json.Delim('[') {
+		entries, err := splitEntries(decoder, data)
+		return 1, entries, err
+	}
+	if token != json.Delim('{') {
+		return 0, nil, errors.New("pokedex must be a JSON object or array")
+	}
+
+	version := 0
+	var entries []entry
+	for decoder.More() {
+		key, err := decoder.Token()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch key {
+		case "version":
+			if err := decoder.Decode(&version); err != nil {
+				return 0, nil, err
+			}
+		case "pokemons":
+			token, err := decoder.Token()
+			if err != nil {
+				return 0, nil, err
+			}
+			if token != json.Delim('[') {
+				return 0, nil, errors.New("pokemons must be an array")
+			}
+			if entries, err = splitEntries(decoder, data); err != nil {
+				return 0, nil, err
+			}
+		default:
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	if _, err := decoder.Token(); err != nil {
+		return 0, nil, err
+	}
+	return version, entries, nil
+}
+
+// splitEntries reads the elements of an array whose opening bracket was just read, up to its closing bracket
+func splitEntries(decoder *json.Decoder, data []byte) ([]entry, error) {
+	var entries []entry
+	for decoder.More() {
+		offset := decoder.InputOffset()
+		for offset < int64(len(data)) && strings.ContainsRune(" \t\r\n,", rune(data[offset])) {
+			offset++
+		}
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{line: lineAt(data, offset), data: raw})
+	}
+	if _, err := decoder.Token(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// decodeEntry decodes one species entry written in the given schema version
+func decodeEntry(version int, data json.RawMessage) (Pokemon, error) {
+	var pokemon Pokemon
+	if version == SchemaVersion {
+		if err := json.Unmarshal(data, &pokemon); err != nil {
+			return pokemon, fmt.Errorf("invalid species entry: %v", err)
+		}
+		return pokemon, nil
+	}
+
+	var legacy legacyPokemon
+	if err := json.Unmarshal(data, &legacy); err != nil {
+		return pokemon, fmt.Errorf("invalid species entry: %v", err)
+	}
+	pokemon = Pokemon{Name: legacy.Name, Types: legacy.Types, Stats: legacy.Stats}
+	number, err := strconv.Atoi(strings.TrimSpace(legacy.Number))
+	if err != nil {
+		return pokemon, fmt.Errorf("%s has number %q, which is not an integer", legacy.Name, legacy.Number)
+	}
+	exp, err := strconv.Atoi(strings.TrimSpace(legacy.Exp))
+	if err != nil {
+		return pokemon, fmt.Errorf("%s has base exp %q, which is not an integer", legacy.Name, legacy.Exp)
+	}
+	pokemon.Number, pokemon.Exp = number, exp
+	return pokemon, nil
+}
+
+// syntaxProblem turns a JSON decoding error into a problem on the line where it happened
+func syntaxProblem(data []byte, err error) Problem {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		return Problem{Line: lineAt(data, syntaxErr.Offset), Message: err.Error()}
+	case errors.As(err, &typeErr):
+		return Problem{Line: lineAt(data, typeErr.Offset), Message: err.Error()}
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		return Problem{Line: lineAt(data, int64(len(data))), Message: "unexpected end of file"}
+	}
+	return Problem{Message: err.Error()}
+}
+
+// lineAt returns the 1-based line of a byte offset
+func lineAt(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
@@ -0,0 +1,166 @@
+package pokedex
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// entryJSON writes a version 2 species entry on one line
+func entryJSON(number int, name string, types string, hp int) string {
+	return fmt.Sprintf(`{"name": %q, "types": [%s], "number": %d, "stats": {"hp": %d, "attack": 49, "defense": 49, "speed": 45, "sp_atk": 65, "sp_def": 65}, "exp": 64}`,
+		name, types, number, hp)
+}
+
+// dexJSON writes a version 2 file with the header on line 1 and entry i on line i+2
+func dexJSON(entries ...string) string {
+	return "{\"version\": 2, \"pokemons\": [\n" + strings.Join(entries, ",\n") + "\n]}\n"
+}
+
+// readString writes a pokedex file and reads it back
+func readString(t *testing.T, data string) ([]Pokemon, error) {
+	t.Helper()
+	filename := filepath.Join(t.TempDir(), "pokedex.json")
+	if err := os.WriteFile(filename, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return Read(filename)
+}
+
+func TestReadProblems(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want []Problem // Only the start of each message is compared
+	}{
+		{
+			"duplicate number",
+			dexJSON(entryJSON(1, "Bulbasaur", `"grass"`, 45), entryJSON(2, "Ivysaur", `"grass"`, 60), entryJSON(1, "Venusaur", `"grass"`, 80)),
+			[]Problem{{4, "Venusaur has number 1, already used by Bulbasaur at line 2"}},
+		},
+		{
+			"duplicate name ignoring case",
+			dexJSON(entryJSON(1, "Bulbasaur", `"grass"`, 45), entryJSON(2, "BULBASAUR", `"grass"`, 60)),
+			[]Problem{{3, "BULBASAUR is already listed at line 2"}},
+		},
+		{
+			"unknown type",
+			dexJSON(entryJSON(1, "Bulbasaur", `"grass", "plant"`, 45)),
+			[]Problem{{2, `Bulbasaur has unknown type "plant"`}},
+		},
+		{
+			"missing types",
+			dexJSON(entryJSON(1, "Bulbasaur", `"grass"`, 45), entryJSON(2, "Ivysaur", ``, 60)),
+			[]Problem{{3, "Ivysaur has no types"}},
+		},
+		{
+			"zero stat",
+			dexJSON(entryJSON(1, "Bulbasaur", `"grass"`, 45), entryJSON(2, "Ivysaur", `"grass"`, 0)),
+			[]Problem{{3, "Ivysaur has hp stat 0"}},
+		},
+		{
+			"several problems",
+			dexJSON(entryJSON(1, "Bulbasaur", ``, 0), entryJSON(1, "Ivysaur", `"grass"`, 60)),
+			[]Problem{{2, "Bulbasaur has no types"}, {2, "Bulbasaur has hp stat 0"}, {3, "Ivysaur has number 1"}},
+		},
+		{
+			"version 1 number that is not an integer",
+			"[\n" + `{"name": "Bulbasaur", "types": ["grass"], "number": "1", "stats": {"hp": 45, "attack": 49, "defense": 49, "speed": 45, "sp_atk": 65, "sp_def": 65}, "exp": "64"},` + "\n" +
+				`{"name": "Ivysaur", "types": ["grass"], "number": "two", "stats": {"hp": 60, "attack": 62, "defense": 63, "speed": 60, "sp_atk": 80, "sp_def": 80}, "exp": "142"}` + "\n]\n",
+			[]Problem{{3, `Ivysaur has number "two", which is not an integer`}},
+		},
+		{
+			"version 1 base exp that is not an integer",
+			"[\n" + `{"name": "Bulbasaur", "types": ["grass"], "number": "1", "stats": {"hp": 45, "attack": 49, "defense": 49, "speed": 45, "sp_atk": 65, "sp_def": 65}, "exp": ""}` + "\n]\n",
+			[]Problem{{2, `Bulbasaur has base exp "", which is not an integer`}},
+		},
+		{
+			"truncated",
+			dexJSON(entryJSON(1, "Bulbasaur", `"grass"`, 45), entryJSON(2, "Ivysaur", `"grass"`, 60))[:150],
+			[]Problem{{2, "unexpected end of file"}},
+		},
+		{
+			"syntax error",
+			"{\"version\": 2, \"pokemons\": [\n" + entryJSON(1, "Bulbasaur", `"grass"`, 45) + ",\n{\"name\": \"Ivysaur\",,}\n]}\n",
+			[]Problem{{3, "invalid character ','"}},
+		},
+		{
+			"wrong field type",
+			dexJSON(entryJSON(1, "Bulbasaur", `"grass"`, 45), `{"name": "Ivysaur", "types": ["grass"], "number": "2"}`),
+			[]Problem{{3, "invalid species entry"}},
+		},
+		{
+			"unsupported version",
+			"{\"version\": 3, \"pokemons\": []}\n",
+			[]Problem{{0, "unsupported schema version 3, expected 2"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := readString(t, test.data)
+			var validation *ValidationError
+			if !errors.As(err, &validation) {
+				t.Fatalf("Read = %v, want a validation error", err)
+			}
+			if len(validation.Problems) != len(test.want) {
+				t.Fatalf("Read found %d problems, want %d:\n%v", len(validation.Problems), len(test.want), err)
+			}
+			for i, want := range test.want {
+				got := validation.Problems[i]
+				if got.Line != want.Line || !strings.HasPrefix(got.Message, want.Message) {
+					t.Errorf("problem %d = %q, want %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestReadUpgradesVersion1(t *testing.T) {
+	data := "[\n" +
+		`{"name": "Bulbasaur", "types": ["grass", "poison"], "number": "1", "stats": {"hp": 45, "attack": 49, "defense": 49, "speed": 45, "sp_atk": 65, "sp_def": 65}, "exp": "64"},` + "\n" +
+		`{"name": "Ivysaur", "types": ["grass", "poison"], "number": " 2 ", "stats": {"hp": 60, "attack": 62, "defense": 63, "speed": 60, "sp_atk": 80, "sp_def": 80}, "exp": "142"}` + "\n]\n"
+	pokemons, err := readString(t, data)
+	if err != nil {
+		t.Fatalf("Read of a valid version 1 file: %v", err)
+	}
+	want := []Pokemon{
+		{Name: "Bulbasaur", Types: []string{"grass", "poison"}, Number: 1, Stats: Stats{HP: 45, Attack: 49, Defense: 49, Speed: 45, SpAtk: 65, SpDef: 65}, Exp: 64},
+		{Name: "Ivysaur", Types: []string{"grass", "poison"}, Number: 2, Stats: Stats{HP: 60, Attack: 62, Defense: 63, Speed: 60, SpAtk: 80, SpDef: 80}, Exp: 142},
+	}
+	if !reflect.DeepEqual(pokemons, want) {
+		t.Errorf("Read = %+v, want %+v", pokemons, want)
+	}
+
+	// Saving writes the upgraded entries in the current version
+	filename := filepath.Join(t.TempDir(), "pokedex.json")
+	if err := Save(filename, pokemons); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := Read(filename)
+	if err != nil || !reflect.DeepEqual(saved, want) {
+		t.Errorf("Read after Save = %+v, %v, want %+v", saved, err, want)
+	}
+	written, _ := os.ReadFile(filename)
+	if !strings.Contains(string(written), `"version": 2`) {
+		t.Errorf("saved file is not version 2:\n%s", written)
+	}
+}
+
+func TestValidateWithoutLines(t *testing.T) {
+	pokemons := []Pokemon{species(1, "Bulbasaur", 64), species(1, "Ivysaur", 0)}
+	problems := Validate(pokemons, nil)
+	want := []Problem{
+		{0, "entry 2: Ivysaur has number 1, already used by Bulbasaur at entry 1"},
+		{0, "entry 2: Ivysaur has base exp 0"},
+	}
+	if !reflect.DeepEqual(problems, want) {
+		t.Errorf("Validate = %q, want %q", problems, want)
+	}
+	if problems := Validate([]Pokemon{species(1, "Bulbasaur", 64)}, nil); len(problems) != 0 {
+		t.Errorf("Validate of a valid entry = %q, want no problems", problems)
+	}
+}
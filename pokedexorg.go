@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/chromedp/chromedp"
+
+	"main/pokedex"
+	"main/scrape"
+)
+
+// PokedexOrgSource renders pokedex.org detail pages in a pool of headless browser tabs
+type PokedexOrgSource struct {
+	SaveDir      string // Directory to keep snapshots of every page in, if set
+	closeBrowser context.CancelFunc
+	tabs         chan context.Context
+	closeTabs    []context.CancelFunc
+}
+
+// NewPokedexOrgSource starts a browser with the given number of tabs
+func NewPokedexOrgSource(tabs int, saveDir string) (*PokedexOrgSource, error) {
+	browser, closeBrowser := chromedp.NewContext(context.Background())
+	if err := chromedp.Run(browser); err != nil {
+		closeBrowser()
+		return nil, fmt.Errorf("failed to start the browser: %v", err)
+	}
+
+	s := &PokedexOrgSource{SaveDir: saveDir, closeBrowser: closeBrowser, tabs: make(chan context.Context, tabs)}
+	for i := 0; i < tabs; i++ {
+		tab, closeTab := chromedp.NewContext(browser)
+		if err := chromedp.Run(tab); err != nil {
+			closeTab()
+			s.Close()
+			return nil, fmt.Errorf("failed to open a browser tab: %v", err)
+		}
+		s.tabs <- tab
+		s.closeTabs = append(s.closeTabs, closeTab)
+	}
+	return s, nil
+}
+
+func (s *PokedexOrgSource) Name() string { return "pokedex.org" }
+
+// Species loads a detail page in a free tab once its panel shows that number, then parses the rendered HTML
+func (s *PokedexOrgSource) Species(ctx context.Context, number int) (pokedex.Pokemon, error) {
+	var tab context.Context
+	select {
+	case tab = <-s.tabs:
+	case <-ctx.Done():
+		return pokedex.Pokemon{}, ctx.Err()
+	}
+	defer func() { s.tabs <- tab }()
+
+	// chromedp needs a context derived from the tab, so tie it to the caller's deadline
+	run, cancel := context.WithCancel(tab)
+	defer cancel()
+	stop := context.AfterFunc(ctx, cancel)
+	defer stop()
+
+	var loaded bool
+	var html string
+	err := chromedp.Run(run,
+		chromedp.Navigate(fmt.Sprintf("https://pokedex.org/#/pokemon/%d", number)),
+		chromedp.WaitVisible(".detail-panel-header", chromedp.ByQuery),
+		// Tabs are reused, so wait until the panel shows this number rather than the previous one
+		chromedp.Poll(fmt.Sprintf(`parseInt((document.querySelector(".detail-header .detail-national-id")?.innerText ?? "").replace("#", ""), 10) === %d &&
+			document.querySelectorAll(".detail-stats-row span").length > 0`, number), &loaded, chromedp.WithPollingTimeout(0)),
+		chromedp.OuterHTML("html", &html, chromedp.ByQuery),
+	)
+	if err != nil {
+		return pokedex.Pokemon{}, fmt.Errorf("failed to extract data for Number %d: %w", number, err)
+	}
+	if err := saveSnapshot(s.SaveDir, PokedexOrgSite, strconv.Itoa(number), html); err != nil {
+		return pokedex.Pokemon{}, err
+	}
+	return scrape.ParsePokedexOrgPage(html, number)
+}
+
+// Close closes every tab and the browser
+func (s *PokedexOrgSource) Close() {
+	for _, closeTab := range s.closeTabs {
+		closeTab()
+	}
+	s.closeBrowser()
+}
@@ -0,0 +1,231 @@
+// Package scrape extracts Pokémon data from the HTML pages the crawler reads, whether fetched live
+// or from saved snapshots
+package scrape
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"main/pokedex"
+)
+
+// ListEntry is what the dex-wide lists say about one Pokémon, by national dex number
+type ListEntry struct {
+	Exp        int
+	EVYield    pokedex.Stats
+	GrowthRate string
+}
+
+// ParsePokedexOrgPage extracts a Pokémon from a pokedex.org detail page showing the given number
+func ParsePokedexOrgPage(html string, number int) (pokedex.Pokemon, error) {
+	var pokemon pokedex.Pokemon
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return pokemon, fmt.Errorf("failed to parse page for Number %d: %v", number, err)
+	}
+
+	pokemon.Name = strings.TrimSpace(doc.Find(".detail-panel-header").First().Text())
+	if pokemon.Name == "" {
+		return pokemon, fmt.Errorf("no name on the page for Number %d", number)
+	}
+	doc.Find(".detail-types span.monster-type").Each(func(_ int, s *goquery.Selection) {
+		pokemon.Types = append(pokemon.Types, strings.ToLower(strings.TrimSpace(s.Text())))
+	})
+
+	// Each stat row has a label span followed by the value
+	stat := func(label string) string {
+		var value string
+		doc.Find(".detail-stats-row span").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			if strings.Contains(s.Text(), label) {
+				value = s.Next().Text()
+				return false
+			}
+			return true
+		})
+		return value
+	}
+
+	fields := []struct {
+		name  string
+		text  string
+		value *int
+	}{
+		{"number", strings.Replace(doc.Find(".detail-header .detail-national-id").First().Text(), "#", "", 1), &pokemon.Number},
+		{"hp", stat("HP"), &pokemon.Stats.HP},
+		{"attack", stat("Attack"), &pokemon.Stats.Attack},
+		{"defense", stat("Defense"), &pokemon.Stats.Defense},
+		{"speed", stat("Speed"), &pokemon.Stats.Speed},
+		{"sp_atk", stat("Sp Atk"), &pokemon.Stats.SpAtk},
+		{"sp_def", stat("Sp Def"), &pokemon.Stats.SpDef},
+	}
+	for _, field := range fields {
+		*field.value, err = strconv.Atoi(strings.TrimSpace(field.text))
+		if err != nil {
+			return pokemon, fmt.Errorf("failed to parse %s %q for Number %d: %w", field.name, field.text, number, err)
+		}
+	}
+	if pokemon.Number != number {
+		return pokemon, fmt.Errorf("page shows Number %d instead of %d", pokemon.Number, number)
+	}
+	parseProfile(doc, &pokemon)
+	parseEvolutions(doc, &pokemon)
+	return pokemon, nil
+}
+
+// Patterns for the profile values and evolution labels of a pokedex.org detail page
+var (
+	numberPattern    = regexp.MustCompile(`[\d.]+`)
+	femalePattern    = regexp.MustCompile(`([\d.]+)%\s*♀`)
+	malePattern      = regexp.MustCompile(`([\d.]+)%\s*♂`)
+	evolutionPattern = regexp.MustCompile(`^(.+?) evolves into (.+?)(?: at level (\d+)| using an? (.+?)| (.+?))?\.?$`)
+)
+
+// parseProfile reads the optional profile of a detail page: height, weight, catch rate, gender ratio
+// and abilities. Values the page does not show are left empty.
+func parseProfile(doc *goquery.Document, pokemon *pokedex.Pokemon) {
+	// Each profile value is a span following its bold label
+	profile := func(label string) string {
+		var value string
+		doc.Find(".monster-minutia strong").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			if strings.Contains(strings.ToLower(s.Text()), label) {
+				value = strings.TrimSpace(s.Next().Text())
+				return false
+			}
+			return true
+		})
+		return value
+	}
+	number := func(text string) float64 {
+		value, _ := strconv.ParseFloat(numberPattern.FindString(text), 64)
+		return value
+	}
+
+	pokemon.Height = number(profile("height"))
+	pokemon.Weight = number(profile("weight"))
+	pokemon.CatchRate = int(number(profile("catch rate")))
+
+	gender := profile("gender")
+	if female := femalePattern.FindStringSubmatch(gender); female != nil {
+		pokemon.Gender = &pokedex.GenderRatio{Female: number(female[1])}
+	} else if male := malePattern.FindStringSubmatch(gender); male != nil {
+		pokemon.Gender = &pokedex.GenderRatio{Female: 100 - number(male[1])}
+	} else if lower := strings.ToLower(gender); strings.Contains(lower, "genderless") || strings.Contains(lower, "n/a") {
+		pokemon.Gender = &pokedex.GenderRatio{Genderless: true}
+	}
+
+	for _, name := range strings.Split(profile("abilities"), ",") {
+		name = strings.TrimSpace(name)
+		hidden := strings.Contains(strings.ToLower(name), "(hidden")
+		if i := strings.Index(name, "("); i >= 0 {
+			name = strings.TrimSpace(name[:i])
+		}
+		if name != "" {
+			pokemon.Abilities = append(pokemon.Abilities, pokedex.Ability{Name: name, Hidden: hidden})
+		}
+	}
+}
+
+// parseEvolutions reads the evolutions of the Pokémon from the labels of its family's evolution chain,
+// written like "Bulbasaur evolves into Ivysaur at level 16."
+func parseEvolutions(doc *goquery.Document, pokemon *pokedex.Pokemon) {
+	doc.Find(".evolution-label").Each(func(_ int, s *goquery.Selection) {
+		match := evolutionPattern.FindStringSubmatch(strings.TrimSpace(s.Text()))
+		if match == nil || !strings.EqualFold(match[1], pokemon.Name) {
+			return
+		}
+		evolution := pokedex.Evolution{Into: match[2], Method: "other", Condition: match[5]}
+		if match[3] != "" {
+			evolution.Method = "level"
+			evolution.Level, _ = strconv.Atoi(match[3])
+		} else if match[4] != "" {
+			evolution.Method = "item"
+			evolution.Condition = match[4]
+		}
+		pokemon.Evolutions = append(pokemon.Evolutions, evolution)
+	})
+}
+
+// ParseYieldTable extracts base EXP and EV yields by national dex number from Bulbapedia's effort value
+// yield list, whose columns are number, sprite, name, EXP, then the EVs from HP to Speed
+func ParseYieldTable(html string) (map[int]ListEntry, error) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse EV yield table: %v", err)
+	}
+
+	entries := make(map[int]ListEntry)
+	doc.Find("table.roundy tbody tr:not(:first-child)").Each(func(_ int, row *goquery.Selection) {
+		cell := func(column int) string {
+			return strings.TrimSpace(row.Find(fmt.Sprintf("td:nth-child(%d)", column)).Text())
+		}
+		number, err := strconv.Atoi(cell(1))
+		if err != nil {
+			return
+		}
+
+		var entry ListEntry
+		fields := []struct {
+			name  string
+			text  string
+			value *int
+		}{
+			{"EXP", cell(4), &entry.Exp},
+			{"HP EV", cell(5), &entry.EVYield.HP},
+			{"Attack EV", cell(6), &entry.EVYield.Attack},
+			{"Defense EV", cell(7), &entry.EVYield.Defense},
+			{"Sp Atk EV", cell(8), &entry.EVYield.SpAtk},
+			{"Sp Def EV", cell(9), &entry.EVYield.SpDef},
+			{"Speed EV", cell(10), &entry.EVYield.Speed},
+		}
+		for _, field := range fields {
+			if *field.value, err = strconv.Atoi(field.text); err != nil {
+				log.Printf("Skipping %s %q for Pokemon Number %d: %v", field.name, field.text, number, err)
+				return
+			}
+		}
+		entries[number] = entry
+	})
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no EV yield table found")
+	}
+	return entries, nil
+}
+
+// ParseGrowthTable adds growth rates from Bulbapedia's experience type list to the entries,
+// taking the first cell of each row that names a growth rate
+func ParseGrowthTable(html string, entries map[int]ListEntry) error {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		return fmt.Errorf("failed to parse experience type table: %v", err)
+	}
+
+	found := 0
+	doc.Find("table tr").Each(func(_ int, row *goquery.Selection) {
+		number, err := strconv.Atoi(strings.TrimSpace(row.Find("td").First().Text()))
+		if err != nil {
+			return
+		}
+		row.Find("td").EachWithBreak(func(_ int, cell *goquery.Selection) bool {
+			rate := strings.ToLower(strings.TrimSpace(cell.Text()))
+			for _, known := range pokedex.GrowthRates {
+				if rate == known {
+					entry := entries[number]
+					entry.GrowthRate = rate
+					entries[number] = entry
+					found++
+					return false
+				}
+			}
+			return true
+		})
+	})
+	if found == 0 {
+		return fmt.Errorf("no experience type table found")
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package scrape
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+
+	"main/pokedex"
+)
+
+// Sites in the fixtures directory at the repository root
+const (
+	pokedexOrgSite = "pokedex.org"
+	bulbapediaSite = "bulbapedia"
+)
+
+// readFixture returns a snapshot from the fixtures directory
+func readFixture(t *testing.T, site string, page string) string {
+	t.Helper()
+	html, err := os.ReadFile(filepath.Join("..", "fixtures", site, page+".html"))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return string(html)
+}
+
+func TestParsePokedexOrgPage(t *testing.T) {
+	bulbasaurLine := []pokedex.Ability{{Name: "Overgrow"}, {Name: "Chlorophyll", Hidden: true}}
+	tests := []struct {
+		number int
+		want   pokedex.Pokemon
+	}{
+		{1, pokedex.Pokemon{
+			Name: "Bulbasaur", Types: []string{"grass", "poison"}, Number: 1,
+			Stats:     pokedex.Stats{HP: 45, Attack: 49, Defense: 49, Speed: 45, SpAtk: 65, SpDef: 65},
+			Abilities: bulbasaurLine, CatchRate: 45, Gender: &pokedex.GenderRatio{Female: 12.5},
+			Height: 0.7, Weight: 6.9,
+			Evolutions: []pokedex.Evolution{{Into: "Ivysaur", Method: "level", Level: 16}},
+		}},
+		{3, pokedex.Pokemon{
+			Name: "Venusaur", Types: []string{"grass", "poison"}, Number: 3,
+			Stats:     pokedex.Stats{HP: 80, Attack: 82, Defense: 83, Speed: 80, SpAtk: 100, SpDef: 100},
+			Abilities: bulbasaurLine, CatchRate: 45, Gender: &pokedex.GenderRatio{Female: 12.5},
+			Height: 2, Weight: 100,
+		}},
+		{25, pokedex.Pokemon{
+			Name: "Pikachu", Types: []string{"electric"}, Number: 25,
+			Stats:     pokedex.Stats{HP: 35, Attack: 55, Defense: 40, Speed: 90, SpAtk: 50, SpDef: 50},
+			Abilities: []pokedex.Ability{{Name: "Static"}, {Name: "Lightning Rod", Hidden: true}},
+			CatchRate: 190, Gender: &pokedex.GenderRatio{Female: 50}, Height: 0.4, Weight: 6,
+			Evolutions: []pokedex.Evolution{{Into: "Raichu", Method: "item", Condition: "Thunder Stone"}},
+		}},
+		{132, pokedex.Pokemon{
+			Name: "Ditto", Types: []string{"normal"}, Number: 132,
+			Stats:     pokedex.Stats{HP: 48, Attack: 48, Defense: 48, Speed: 48, SpAtk: 48, SpDef: 48},
+			Abilities: []pokedex.Ability{{Name: "Limber"}, {Name: "Imposter", Hidden: true}},
+			CatchRate: 35, Gender: &pokedex.GenderRatio{Genderless: true}, Height: 0.3, Weight: 4,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(strconv.Itoa(test.number), func(t *testing.T) {
+			html := readFixture(t, pokedexOrgSite, strconv.Itoa(test.number))
+			got, err := ParsePokedexOrgPage(html, test.number)
+			if err != nil {
+				t.Fatalf("ParsePokedexOrgPage: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("ParsePokedexOrgPage(%d)\n got %+v\nwant %+v", test.number, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParsePokedexOrgPageErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		html   string
+		number int
+		want   string
+	}{
+		{"wrong page", readFixture(t, pokedexOrgSite, "1"), 2, "shows Number 1 instead of 2"},
+		{"no name", "<html><body></body></html>", 1, "no name"},
+		{"missing stat", strings.Replace(readFixture(t, pokedexOrgSite, "1"), "<span>HP</span><span>45</span>", "", 1), 1, "failed to parse hp"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := ParsePokedexOrgPage(test.html, test.number)
+			if err == nil || !strings.Contains(err.Error(), test.want) {
+				t.Errorf("ParsePokedexOrgPage error = %v, want it to mention %q", err, test.want)
+			}
+		})
+	}
+}
+
+func TestParseEvolutions(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []string
+		want   []pokedex.Evolution
+	}{
+		{"level", []string{"Bulbasaur evolves into Ivysaur at level 16."},
+			[]pokedex.Evolution{{Into: "Ivysaur", Method: "level", Level: 16}}},
+		{"item", []string{"Bulbasaur evolves into Ivysaur using a Leaf Stone."},
+			[]pokedex.Evolution{{Into: "Ivysaur", Method: "item", Condition: "Leaf Stone"}}},
+		{"item with an", []string{"Bulbasaur evolves into Ivysaur using an Oval Stone"},
+			[]pokedex.Evolution{{Into: "Ivysaur", Method: "item", Condition: "Oval Stone"}}},
+		{"other", []string{"Bulbasaur evolves into Ivysaur with high friendship."},
+			[]pokedex.Evolution{{Into: "Ivysaur", Method: "other", Condition: "with high friendship"}}},
+		{"no condition", []string{"Bulbasaur evolves into Ivysaur."},
+			[]pokedex.Evolution{{Into: "Ivysaur", Method: "other"}}},
+		{"other species", []string{"Ivysaur evolves into Venusaur at level 32."}, nil},
+		{"several", []string{"Bulbasaur evolves into Ivysaur at level 16.", "BULBASAUR evolves into Venusaur at level 32."},
+			[]pokedex.Evolution{{Into: "Ivysaur", Method: "level", Level: 16}, {Into: "Venusaur", Method: "level", Level: 32}}},
+		{"unrelated text", []string{"Bulbasaur does not evolve."}, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var html strings.Builder
+			for _, label := range test.labels {
+				html.WriteString(`<div class="evolution-label"><span>` + label + `</span></div>`)
+			}
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(html.String()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			pokemon := pokedex.Pokemon{Name: "Bulbasaur"}
+			parseEvolutions(doc, &pokemon)
+			if !reflect.DeepEqual(pokemon.Evolutions, test.want) {
+				t.Errorf("parseEvolutions = %+v, want %+v", pokemon.Evolutions, test.want)
+			}
+		})
+	}
+}
+
+func TestParseYieldTable(t *testing.T) {
+	entries, err := ParseYieldTable(readFixture(t, bulbapediaSite, "exp"))
+	if err != nil {
+		t.Fatalf("ParseYieldTable: %v", err)
+	}
+	tests := []struct {
+		number int
+		want   ListEntry
+	}{
+		{1, ListEntry{Exp: 64, EVYield: pokedex.Stats{SpAtk: 1}}},
+		{3, ListEntry{Exp: 263, EVYield: pokedex.Stats{SpAtk: 2, SpDef: 1}}},
+		{25, ListEntry{Exp: 112, EVYield: pokedex.Stats{Speed: 2}}},
+		{132, ListEntry{Exp: 101, EVYield: pokedex.Stats{HP: 1}}},
+	}
+	for _, test := range tests {
+		if got := entries[test.number]; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("entry %d = %+v, want %+v", test.number, got, test.want)
+		}
+	}
+	// Mewtwo's row has no EXP and is skipped
+	if len(entries) != 5 {
+		t.Errorf("ParseYieldTable found %d entries, want 5", len(entries))
+	}
+
+	if _, err := ParseYieldTable("<html><body><p>Not found</p></body></html>"); err == nil {
+		t.Errorf("ParseYieldTable of a page without the table succeeded")
+	}
+}
+
+func TestParseGrowthTable(t *testing.T) {
+	entries := map[int]ListEntry{1: {Exp: 64}}
+	if err := ParseGrowthTable(readFixture(t, bulbapediaSite, "growth"), entries); err != nil {
+		t.Fatalf("ParseGrowthTable: %v", err)
+	}
+	tests := []struct {
+		number int
+		want   ListEntry
+	}{
+		{1, ListEntry{Exp: 64, GrowthRate: "medium slow"}},
+		{25, ListEntry{GrowthRate: "medium fast"}},
+		{132, ListEntry{GrowthRate: "medium fast"}},
+	}
+	for _, test := range tests {
+		if got := entries[test.number]; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("entry %d = %+v, want %+v", test.number, got, test.want)
+		}
+	}
+
+	if err := ParseGrowthTable("<table><tr><td>0001</td><td>Bulbasaur</td></tr></table>", map[int]ListEntry{}); err == nil {
+		t.Errorf("ParseGrowthTable of a table without growth rates succeeded")
+	}
+}
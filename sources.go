@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"main/pokedex"
+	"main/scrape"
+)
+
+// SpeciesSource provides a Pokémon's number, name, types and base stats by national dex number
+type SpeciesSource interface {
+	Name() string
+	Species(ctx context.Context, number int) (pokedex.Pokemon, error)
+	Close()
+}
+
+// ListSource provides the data kept in dex-wide lists, by national dex number
+type ListSource interface {
+	Name() string
+	Lists(ctx context.Context) (map[int]scrape.ListEntry, error)
+}
+
+// Snapshot sites, used as subdirectories of a fixtures directory
+const (
+	PokedexOrgSite = "pokedex.org"
+	BulbapediaSite = "bulbapedia"
+)
+
+// FixtureSource reads HTML snapshots saved from the live sites, laid out as <Dir>/pokedex.org/<number>.html,
+// <Dir>/bulbapedia/exp.html and <Dir>/bulbapedia/growth.html, so the dex can be rebuilt offline
+type FixtureSource struct {
+	Dir string
+}
+
+func (s *FixtureSource) Name() string { return "local snapshots in " + s.Dir }
+
+func (s *FixtureSource) Species(ctx context.Context, number int) (pokedex.Pokemon, error) {
+	html, err := os.ReadFile(snapshotPath(s.Dir, PokedexOrgSite, strconv.Itoa(number)))
+	if err != nil {
+		return pokedex.Pokemon{}, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+	return scrape.ParsePokedexOrgPage(string(html), number)
+}
+
+func (s *FixtureSource) Lists(ctx context.Context) (map[int]scrape.ListEntry, error) {
+	yields, err := os.ReadFile(snapshotPath(s.Dir, BulbapediaSite, "exp"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+	entries, err := scrape.ParseYieldTable(string(yields))
+	if err != nil {
+		return nil, err
+	}
+	// Snapshots saved before growth rates were crawled do not have this page
+	growth, err := os.ReadFile(snapshotPath(s.Dir, BulbapediaSite, "growth"))
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Printf("No growth rate snapshot in %s, skipping growth rates", s.Dir)
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %v", err)
+	}
+	return entries, scrape.ParseGrowthTable(string(growth), entries)
+}
+
+func (s *FixtureSource) Close() {}
+
+// snapshotPath returns where the snapshot of a page is kept in a fixtures directory
+func snapshotPath(dir string, site string, page string) string {
+	return filepath.Join(dir, site, page+".html")
+}
+
+// saveSnapshot keeps a copy of a fetched page in a fixtures directory, doing nothing if dir is empty
+func saveSnapshot(dir string, site string, page string, html string) error {
+	if dir == "" {
+		return nil
+	}
+	path := snapshotPath(dir, site, page)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to save snapshot: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(html), 0644); err != nil {
+		return fmt.Errorf("failed to save snapshot: %v", err)
+	}
+	return nil
+}
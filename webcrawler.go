@@ -2,138 +2,317 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"os"
-	"strconv"
-	"strings"
+	"path/filepath"
+	"sync"
 	"time"
 
-	"github.com/chromedp/chromedp"
-	"github.com/gocolly/colly"
+	"main/pokedex"
 )
 
+// Crawl settings, adjustable from the command line
+var (
+	workers     = flag.Int("workers", 4, "number of browser tabs fetching pages in parallel")
+	rate        = flag.Float64("rate", 2, "maximum page loads per second across all tabs")
+	pageTimeout = flag.Duration("page-timeout", 30*time.Second, "time limit for loading and reading one page")
+	retries     = flag.Int("retries", 3, "attempts per page before giving up")
+	first       = flag.Int("first", 1, "first national dex number to crawl")
+	last        = flag.Int("last", 640, "last national dex number to crawl")
+	dataDir     = pokedex.DataDirFlag()
+	checkpoint  = flag.String("checkpoint", "", "file recording every fetched Pokémon, so an interrupted crawl resumes (default <data>/crawl_checkpoint.ndjson)")
+	failures    = flag.String("failures", "", "file listing the numbers that failed every retry (default <data>/crawl_failures.json)")
+	force       = flag.Bool("force", false, "write pokedex.json even when some numbers are missing")
+	source      = flag.String("source", "web", "where to read Pokémon from: web (pokedex.org and Bulbapedia) or local (saved HTML snapshots)")
+	fixtures    = flag.String("fixtures", "fixtures", "directory of saved HTML snapshots read by -source local")
+	saveHTML    = flag.String("save-html", "", "directory to save a snapshot of every fetched page in, for later use with -source local")
+	diffReport  = flag.String("diff-report", "", "file to write the changes against the current pokedex.json to, as JSON (default <data>/crawl_diff.json)")
+	dryRun      = flag.Bool("dry-run", false, "only report the changes, without writing pokedex.json")
+)
 
-type Pokemon struct {
-	Name   string   `json:"name"`
-	Types  []string `json:"types"`
-	Number string   `json:"number"`
-	Stats  Stats    `json:"stats"`
-	Exp    string   `json:"exp"`
+// fetchResult is the outcome of fetching one national dex number
+type fetchResult struct {
+	number  int
+	pokemon pokedex.Pokemon
+	err     error
 }
 
-type Stats struct {
-	HP     int `json:"hp"`
-	Attack int `json:"attack"`
-	Defense int `json:"defense"`
-	Speed  int `json:"speed"`
-	SpAtk  int `json:"sp_atk"`
-	SpDef  int `json:"sp_def"`
-}
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "validate":
+			os.Exit(validate(os.Args[2:]))
+		case "export":
+			os.Exit(export(os.Args[2:]))
+		}
+	}
 
-func fetchPokemonData(ctx context.Context, i int) (Pokemon, error) {
-	var pokemon Pokemon
-	var numberStr, hpStr, attackStr, defenseStr, speedStr, spAtkStr, spDefStr string
-
-	err := chromedp.Run(ctx,
-		chromedp.Navigate(fmt.Sprintf("https://pokedex.org/#/pokemon/%d", i)),
-		chromedp.Sleep(5*time.Second),
-		chromedp.Evaluate(`document.querySelector(".detail-header .detail-national-id").innerText.replace("#", "")`, &numberStr),
-		chromedp.Evaluate(`document.querySelector(".detail-panel-header").innerText`, &pokemon.Name),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('.detail-types span.monster-type')).map(elem => elem.innerText.toLowerCase())`, &pokemon.Types),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('.detail-stats-row span')).filter(span => span.innerText.includes('HP'))[0].nextElementSibling.innerText`, &hpStr),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('.detail-stats-row span')).filter(span => span.innerText.includes('Attack'))[0].nextElementSibling.innerText`, &attackStr),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('.detail-stats-row span')).filter(span => span.innerText.includes('Defense'))[0].nextElementSibling.innerText`, &defenseStr),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('.detail-stats-row span')).filter(span => span.innerText.includes('Speed'))[0].nextElementSibling.innerText`, &speedStr),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('.detail-stats-row span')).filter(span => span.innerText.includes('Sp Atk'))[0].nextElementSibling.innerText`, &spAtkStr),
-		chromedp.Evaluate(`Array.from(document.querySelectorAll('.detail-stats-row span')).filter(span => span.innerText.includes('Sp Def'))[0].nextElementSibling.innerText`, &spDefStr),
-	)
+	flag.Parse()
+	if *source != "web" && *source != "local" {
+		log.Fatalf("Unknown source %q, expected web or local", *source)
+	}
+	if *workers < 1 || *rate <= 0 || *pageTimeout <= 0 || *retries < 1 || *first < 1 || *last < *first {
+		log.Fatalf("Invalid crawl settings: need -workers >= 1, -rate > 0, -page-timeout > 0, -retries >= 1 and 1 <= -first <= -last")
+	}
+	if err := pokedex.CheckDataDir(*dataDir); err != nil {
+		log.Fatalf("%v", err)
+	}
+	dexFile := filepath.Join(*dataDir, pokedex.DexFile)
+	inDataDir(checkpoint, "crawl_checkpoint.ndjson")
+	inDataDir(failures, "crawl_failures.json")
+	inDataDir(diffReport, "crawl_diff.json")
 
+	header := checkpointHeader{Source: *source, First: *first, Last: *last}
+	fetched, err := loadCheckpoint(*checkpoint, header)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	progress, err := openCheckpoint(*checkpoint, header)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	failed, err := crawlPokedex(fetched, progress)
+	progress.Close()
 	if err != nil {
-		return pokemon, fmt.Errorf("failed to extract data for Number %d: %w", i, err)
+		log.Fatalf("%v", err)
+	}
+	if err := writeFailureReport(*failures, failed); err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	pokemon.Number = strings.TrimSpace(numberStr)
-	pokemon.Stats.HP, _ = strconv.Atoi(strings.TrimSpace(hpStr))
-	pokemon.Stats.Attack, _ = strconv.Atoi(strings.TrimSpace(attackStr))
-	pokemon.Stats.Defense, _ = strconv.Atoi(strings.TrimSpace(defenseStr))
-	pokemon.Stats.Speed, _ = strconv.Atoi(strings.TrimSpace(speedStr))
-	pokemon.Stats.SpAtk, _ = strconv.Atoi(strings.TrimSpace(spAtkStr))
-	pokemon.Stats.SpDef, _ = strconv.Atoi(strings.TrimSpace(spDefStr))
+	var pokemonList []pokedex.Pokemon
+	var missing []int
+	for i := *first; i <= *last; i++ {
+		if pokemon, ok := fetched[i]; ok {
+			pokemonList = append(pokemonList, pokemon)
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) > 0 {
+		log.Printf("%d of %d Pokemon could not be fetched, see %s", len(missing), *last-*first+1, *failures)
+		if !*force {
+			log.Fatalf("Not writing pokedex.json: run again to retry the missing numbers, or pass -force to write what was fetched")
+		}
+	}
 
-	return pokemon, nil
-}
+	lists := ListSource(&BulbapediaSource{SaveDir: *saveHTML})
+	if *source == "local" {
+		lists = &FixtureSource{Dir: *fixtures}
+	}
+	entries, err := lists.Lists(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to read the Pokemon lists from %s: %v", lists.Name(), err)
+	}
+	for i := range pokemonList {
+		entry, found := entries[pokemonList[i].Number]
+		if !found {
+			continue
+		}
+		pokemonList[i].Exp = entry.Exp
+		pokemonList[i].GrowthRate = entry.GrowthRate
+		if entry.EVYield != (pokedex.Stats{}) {
+			evYield := entry.EVYield
+			pokemonList[i].EVYield = &evYield
+		}
+	}
 
-func main() {
-	ctx, cancel := chromedp.NewContext(context.Background())
-	defer cancel()
+	// A -first to -last range that leaves out part of the current dex only updates that range
+	previous := loadPrevious(dexFile)
+	merged := pokedex.MergeRange(previous, pokemonList, *first, *last)
+	if kept := len(merged) - len(pokemonList); kept > 0 {
+		fmt.Printf("Keeping %d species outside #%d-#%d from %s\n", kept, *first, *last, dexFile)
+	}
+	if _, err := pokedex.New(merged); err != nil {
+		log.Fatalf("Scraped data does not match the pokedex schema: %v", err)
+	}
 
-	ctx, cancel = context.WithTimeout(ctx, 900*time.Second)
-	defer cancel()
+	diff, err := pokedex.Diff(previous, merged)
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	fmt.Print(diff)
+	if err := writeDiffReport(*diffReport, diff); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if *dryRun {
+		fmt.Println("Dry run, pokedex.json was not written")
+		return
+	}
 
-	var pokemonList []Pokemon
+	if err := pokedex.Save(dexFile, merged); err != nil {
+		log.Fatalf("Error writing JSON data: %v", err)
+	}
+	fmt.Printf("Pokemon data saved to %s\n", dexFile)
 
-	for i := 1; i <= 640; i++ {
-		var pokemon Pokemon
-		var err error
+	if len(missing) == 0 {
+		// The dex is complete, so the next crawl starts from scratch
+		if err := os.Remove(*checkpoint); err != nil {
+			log.Printf("Failed to remove checkpoint: %v", err)
+		}
+	}
+}
 
-		for retry := 0; retry < 3; retry++ {
-			pokemon, err = fetchPokemonData(ctx, i)
-			if err == nil {
-				break
-			}
-			log.Printf("Retry %d for Pokemon Number %d: %v", retry+1, i, err)
-			time.Sleep(2 * time.Second)
+// crawlPokedex fetches every number from -first to -last that is not fetched yet from the species
+// source, with a pool of workers sharing one rate limit for the web. Each Pokémon is added to fetched
+// and the checkpoint as it arrives, and numbers that fail every retry are returned instead of stopping the crawl.
+func crawlPokedex(fetched map[int]pokedex.Pokemon, progress *Checkpoint) ([]crawlFailure, error) {
+	var todo []int
+	for i := *first; i <= *last; i++ {
+		if _, ok := fetched[i]; !ok {
+			todo = append(todo, i)
 		}
+	}
+	if len(todo) == 0 {
+		return nil, nil
+	}
+	fmt.Printf("Fetching %d Pokemon, %d already in the checkpoint\n", len(todo), *last-*first+1-len(todo))
 
+	var species SpeciesSource
+	var limiter <-chan time.Time
+	if *source == "local" {
+		species = &FixtureSource{Dir: *fixtures}
+		// Snapshots are read from disk, so there is nothing to rate limit
+		unlimited := make(chan time.Time)
+		close(unlimited)
+		limiter = unlimited
+	} else {
+		web, err := NewPokedexOrgSource(*workers, *saveHTML)
 		if err != nil {
-			log.Fatalf("Failed to extract data for pokemon number %d: %v", i, err)
+			return nil, err
 		}
-
-		pokemonList = append(pokemonList, pokemon)
-		fmt.Printf("Data for Pokemon Number %d\n", i)
+		species = web
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / *rate))
+		defer ticker.Stop()
+		limiter = ticker.C
 	}
+	defer species.Close()
 
-	c := colly.NewCollector(
-		colly.AllowedDomains("bulbapedia.bulbagarden.net"),
-	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
 
-	expMap := make(map[string]string)
+	numbers := make(chan int)
+	results := make(chan fetchResult)
+	var wg sync.WaitGroup
+	for w := 0; w < *workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range numbers {
+				pokemon, err := fetchWithRetries(ctx, species, limiter, i)
+				results <- fetchResult{number: i, pokemon: pokemon, err: err}
+			}
+		}()
+	}
 
-	c.OnHTML("table.roundy tbody tr:not(:first-child)", func(e *colly.HTMLElement) {
-		Number := strings.Trim(e.ChildText("td:nth-child(1)"), "\n ")
-		exp := strings.Trim(e.ChildText("td:nth-child(4)"), "\n ")
-		Number = strings.TrimLeft(Number, "0")
+	stop := make(chan struct{})
+	go func() {
+		defer close(numbers)
+		for _, i := range todo {
+			select {
+			case numbers <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
 
-		if Number != "" && exp != "" {
-			expMap[Number] = exp
+	var failed []crawlFailure
+	var fatal error
+	done := 0
+	for result := range results {
+		if fatal != nil {
+			continue
 		}
-	})
+		done++
+		if result.err != nil {
+			log.Printf("Giving up on Pokemon Number %d: %v", result.number, result.err)
+			failed = append(failed, crawlFailure{Number: result.number, Error: result.err.Error()})
+			continue
+		}
+		if err := progress.Add(result.pokemon); err != nil {
+			// Without a checkpoint the work cannot be kept, so stop fetching
+			fatal = err
+			close(stop)
+			cancel()
+			continue
+		}
+		fetched[result.number] = result.pokemon
+		fmt.Printf("Data for Pokemon Number %d (%d/%d)\n", result.number, done, len(todo))
+	}
+	return failed, fatal
+}
 
-	c.Visit("https://bulbapedia.bulbagarden.net/wiki/List_of_Pok%C3%A9mon_by_effort_value_yield_(Generation_IX)")
+// fetchWithRetries fetches one number from the source, waiting for the rate limiter before every
+// attempt and giving each attempt its own timeout
+func fetchWithRetries(ctx context.Context, species SpeciesSource, limiter <-chan time.Time, i int) (pokedex.Pokemon, error) {
+	var pokemon pokedex.Pokemon
+	var err error
+	for retry := 0; retry < *retries; retry++ {
+		if retry > 0 {
+			log.Printf("Retry %d for Pokemon Number %d: %v", retry, i, err)
+		}
+		select {
+		case <-limiter:
+		case <-ctx.Done():
+			return pokemon, ctx.Err()
+		}
 
-	for i := range pokemonList {
-		if exp, found := expMap[pokemonList[i].Number]; found {
-			pokemonList[i].Exp = exp
+		page, cancel := context.WithTimeout(ctx, *pageTimeout)
+		pokemon, err = species.Species(page, i)
+		cancel()
+		if err == nil || ctx.Err() != nil {
+			break
 		}
 	}
+	return pokemon, err
+}
 
-	c.OnError(func(_ *colly.Response, err error) {
-		log.Println("Something went wrong:", err)
-	})
-
-	pokemonJSON, err := json.MarshalIndent(pokemonList, "", "    ")
+// loadPrevious reads the pokedex the crawl replaces, treating a missing or unreadable file as empty
+func loadPrevious(filename string) []pokedex.Pokemon {
+	if _, err := os.Stat(filename); err != nil {
+		return nil
+	}
+	previous, err := pokedex.Read(filename)
 	if err != nil {
-		fmt.Println("Error encoding Pokemon data to JSON:", err)
-		return
+		log.Printf("Comparing against an empty pokedex, the current one could not be read: %v", err)
 	}
+	return previous
+}
 
-	err = os.WriteFile("./pokebat/pokedex.json", pokemonJSON, 0644)
-	err = os.WriteFile("./pokecat/pokedex.json", pokemonJSON, 0644)
-	if err != nil {
-		fmt.Println("Error writing JSON data:", err)
-		return
+// inDataDir points a file flag left empty at a file of that name in the data directory
+func inDataDir(path *string, name string) {
+	if *path == "" {
+		*path = filepath.Join(*dataDir, name)
+	}
+}
+
+// validate checks pokedex files against the schema before they are deployed, defaulting to the
+// dex in the data directory, and returns the exit status
+func validate(args []string) int {
+	flags := flag.NewFlagSet("validate", flag.ExitOnError)
+	dataDir := pokedex.DataDirFlagSet(flags)
+	flags.Parse(args)
+
+	files := flags.Args()
+	if len(files) == 0 {
+		files = []string{filepath.Join(*dataDir, pokedex.DexFile)}
+	}
+	status := 0
+	for _, file := range files {
+		pokemons, err := pokedex.Read(file)
+		if err != nil {
+			fmt.Println(err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %d species, OK\n", file, len(pokemons))
 	}
-	fmt.Println("Pokemon data saved to pokedex.json")
+	return status
 }